@@ -11,7 +11,6 @@ import (
 type CmgrConfig struct {
 	Port     string
 	OnAccept func(net.Conn)
-	OnConn   func(net.Conn, string) // takes the connection  and the string
 }
 
 type connmgr struct {
@@ -52,23 +51,8 @@ func NewConnMgr(cfg CmgrConfig) *connmgr {
 	return cnnmgr
 }
 
-// Connect dials a connection with its string, then on succession
-// we pass the connection and the address to the OnConn method
-func (c *connmgr) Connect(addr string) error {
-	conn, err := c.Dial(addr)
-	if err != nil {
-		return err
-	}
-
-	if c.CmgrConfig.OnConn != nil {
-		go c.CmgrConfig.OnConn(conn, addr)
-	}
-
-	return nil
-}
-
-// Dial dials up a connection, given its address string
-func (c *connmgr) Dial(addr string) (net.Conn, error) {
+// dial dials up a connection, given its address string
+func dial(addr string) (net.Conn, error) {
 	dialTimeout := 1 * time.Second
 	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
 	if err != nil {
@@ -64,22 +64,22 @@ func main() {
 	defer srv.Close()
 
 	//start the connection manager
-	connMgr := NewConnMgr(CmgrConfig{
+	_ = NewConnMgr(CmgrConfig{
 		Port:     port,
-		OnAccept: srv.OnAccept,
-		OnConn:   srv.OnConnection,
+		OnAccept: srv.peerMgr.Accept,
 	})
 
-	// fetch neighbours addresses from the Seeder
-	ips := ConnectToSeeder()
-
-	// trying to connect to the peers
-	for _, ip := range ips {
-		if err := connMgr.Connect(ip); err != nil {
-			log.WithField("IP", ip).Warnln(err)
-		}
+	// fetch neighbours addresses from the Seeder, and let the peer manager
+	// connect to them. Without the Seeder, the peer manager falls back to the
+	// address book.
+	seeds := ConnectToSeeder()
+	if len(seeds) == 0 {
+		log.WithField("prefix", "main").Warnf("no seeds available, bootstrapping from %d known addresses", srv.addrMgr.Len())
 	}
 
+	srv.peerMgr.AddAddresses(seeds...)
+	srv.peerMgr.Start()
+
 	fmt.Fprintln(os.Stdout, "initialization complete. opening console...")
 
 	// Start interactive shell
@@ -15,7 +15,7 @@ import (
 
 // ConnectToSeeder initializes the connection with the Voucher Seeder
 func ConnectToSeeder() []string {
-	if cfg.Get().General.Network == "testnet" {
+	if cfg.Get().General.Network != "mainnet" {
 		fixedNetwork := cfg.Get().Network.Seeder.Fixed
 		if len(fixedNetwork) > 0 {
 			log.Infof("Fixed-network config activated")
@@ -29,14 +29,25 @@ func ConnectToSeeder() []string {
 		return nil
 	}
 
+	// an unreachable seeder is not fatal, the node can still bootstrap from
+	// its address book
 	conn, err := net.Dial("tcp", seeders[0])
 	if err != nil {
-		panic(err)
+		log.WithFields(log.Fields{
+			"process": "main",
+			"error":   err,
+		}).Errorln("could not connect to voucher seeder")
+		return nil
 	}
 	log.WithField("prefix", "main").Debugln("connected to voucher seeder")
 
 	if err := completeChallenge(conn); err != nil {
-		panic(err)
+		log.WithFields(log.Fields{
+			"process": "main",
+			"error":   err,
+		}).Errorln("voucher seeder challenge failed")
+		_ = conn.Close()
+		return nil
 	}
 	log.WithField("prefix", "main").Debugln("voucher seeder challenge completed")
 
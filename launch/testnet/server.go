@@ -3,12 +3,16 @@ package main
 import (
 	"bytes"
 	"net"
+	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/chain"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/mempool"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/addrmgr"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/dupemap"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermgr"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing/chainsync"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
@@ -16,6 +20,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	"github.com/dusk-network/dusk-blockchain/pkg/rpc"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
 
 	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
 )
@@ -26,7 +31,14 @@ type Server struct {
 	rpcBus   *wire.RPCBus
 	chain    *chain.Chain
 	dupeMap  *dupemap.DupeMap
-	counter  *chainsync.Counter
+	// the components shared by the peers
+	shared  *peer.Shared
+	peerMgr *peermgr.Manager
+	addrMgr *addrmgr.AddrManager
+	// long-term identity of the node on the peer network
+	identity ed25519.PrivateKey
+	// the network the node is running on
+	magic protocol.Magic
 }
 
 // Setup creates a new EventBus, generates the BLS and the ED25519 Keys, launches a new `CommitteeStore`, launches the Blockchain process and inits the Stake and Blind Bid channels
@@ -61,26 +73,100 @@ func Setup() *Server {
 		}
 	}
 
+	identity, err := peer.LoadIdentity(cfg.Get().Network.Identity)
+	if err != nil {
+		panic(err)
+	}
+
 	// creating the Server
 	srv := &Server{
 		eventBus: eventBus,
 		rpcBus:   rpcBus,
 		chain:    chain,
 		dupeMap:  dupeBlacklist,
-		counter:  chainsync.NewCounter(eventBus),
+		identity: identity,
+		magic:    protocol.MagicFromConfig(),
 	}
 
+	peersCfg := cfg.Get().Network.Peers
+	srv.peerMgr = peermgr.New(peermgr.Config{
+		TargetOutbound:  peersCfg.TargetOutbound,
+		OverlayOutbound: peersCfg.OverlayOutbound,
+		MaxInbound:      peersCfg.MaxInbound,
+		MaxPerIP:        peersCfg.MaxPerIP,
+		BanThreshold:    peersCfg.BanThreshold,
+		BanDuration:     time.Duration(peersCfg.BanDuration) * time.Second,
+		BanList:         peersCfg.BanList,
+	}, dial, srv.onPeer)
+
+	// the address book is fed by the Addr messages of the peers, and
+	// provides candidates to the peer manager
+	srv.addrMgr = addrmgr.New(peersCfg.AddrBook)
+	srv.addrMgr.Start(eventBus)
+	srv.peerMgr.UseAddressBook(srv.addrMgr)
+
 	// Connecting to the log based monitoring system
 	if err := ConnectToLogMonitor(eventBus); err != nil {
 		panic(err)
 	}
 
-	gossip := processing.NewGossip(protocol.TestNet)
+	gossip := processing.NewGossip(srv.magic)
 	eventBus.RegisterPreprocessor(string(topics.Gossip), gossip)
 
+	broadcaster, err := newBroadcaster(eventBus, identity)
+	if err != nil {
+		panic(err)
+	}
+
+	srv.shared = &peer.Shared{
+		Counter:     chainsync.NewCounter(eventBus),
+		Rejector:    processing.NewRejector(eventBus),
+		Requests:    processing.NewRequestTracker(),
+		InvRelay:    processing.NewInvRelay(eventBus),
+		Dandelion:   processing.NewDandelion(eventBus),
+		Broadcaster: broadcaster,
+		Limits:      newLimits(),
+	}
+
+	// the overlay is given connections to the nodes filling its buckets
+	if kadcast, ok := broadcaster.(*processing.Kadcast); ok {
+		srv.peerMgr.UseOverlay(kadcast)
+	}
+
 	return srv
 }
 
+// newBroadcaster returns the Broadcaster selected in the configuration.
+// Flooding is the fallback.
+func newBroadcaster(eventBus *wire.EventBus, identity ed25519.PrivateKey) (processing.Broadcaster, error) {
+	broadcastCfg := cfg.Get().Network.Broadcast
+	switch broadcastCfg.Overlay {
+	case "kadcast":
+		return processing.NewKadcast(eventBus, identity.Public().(ed25519.PublicKey), broadcastCfg.Redundancy)
+	case "", "flood":
+		return processing.NewFlood(eventBus), nil
+	default:
+		log.WithField("overlay", broadcastCfg.Overlay).Warnln("unknown broadcast overlay, flooding the gossip")
+		return processing.NewFlood(eventBus), nil
+	}
+}
+
+// newLimits returns the traffic limits set in the configuration, on top of
+// the default topic rates.
+func newLimits() *processing.Limits {
+	limitsCfg := cfg.Get().Network.Limits
+	rates := make(map[topics.Topic]float64, len(processing.DefaultTopicRates))
+	for topic, rate := range processing.DefaultTopicRates {
+		rates[topic] = rate
+	}
+
+	for topic, rate := range limitsCfg.TopicRates {
+		rates[topics.Topic(topic)] = rate
+	}
+
+	return processing.NewLimits(float64(limitsCfg.GlobalBandwidth), float64(limitsCfg.PeerBandwidth), rates)
+}
+
 func launchDupeMap(eventBus wire.EventBroker) *dupemap.DupeMap {
 	acceptedBlockChan, _ := consensus.InitAcceptedBlockUpdate(eventBus)
 	dupeBlacklist := dupemap.NewDupeMap(1)
@@ -94,21 +180,27 @@ func launchDupeMap(eventBus wire.EventBroker) *dupemap.DupeMap {
 	return dupeBlacklist
 }
 
-// OnAccept read incoming packet from the peers
-func (s *Server) OnAccept(conn net.Conn) {
+// onPeer is called by the peer manager for every new connection. It performs
+// the handshake, and spawns the peer Reader and Writer.
+func (s *Server) onPeer(conn net.Conn, inbound bool) (*peer.Remote, error) {
+	if inbound {
+		return s.onAccept(conn)
+	}
+
+	return s.onConnection(conn)
+}
+
+// onAccept read incoming packet from the peers
+func (s *Server) onAccept(conn net.Conn) (*peer.Remote, error) {
 	writeQueueChan := make(chan *bytes.Buffer, 1000)
 	exitChan := make(chan struct{}, 1)
-	peerReader, err := peer.NewReader(conn, protocol.TestNet, s.dupeMap, s.eventBus, s.rpcBus, s.counter, writeQueueChan, exitChan)
+	peerReader, err := peer.NewReader(conn, s.magic, s.dupeMap, s.eventBus, s.rpcBus, s.shared, writeQueueChan, exitChan)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	if err := peerReader.Accept(); err != nil {
-		log.WithFields(log.Fields{
-			"process": "server",
-			"error":   err,
-		}).Warnln("problem performing handshake")
-		return
+	if err := peerReader.Accept(s.identity, s.bestHeight()); err != nil {
+		return nil, err
 	}
 	log.WithFields(log.Fields{
 		"process": "server",
@@ -117,21 +209,19 @@ func (s *Server) OnAccept(conn net.Conn) {
 
 	go peerReader.ReadLoop()
 
-	peerWriter := peer.NewWriter(conn, protocol.TestNet, s.eventBus)
+	peerWriter := peer.NewWriter(conn, s.magic, s.shared.Broadcaster)
+	peerWriter.ShareSession(peerReader.Connection)
 	go peerWriter.Serve(writeQueueChan, exitChan)
+	return peerReader.Remote(), nil
 }
 
-// OnConnection is the callback for writing to the peers
-func (s *Server) OnConnection(conn net.Conn, addr string) {
+// onConnection is the callback for writing to the peers
+func (s *Server) onConnection(conn net.Conn) (*peer.Remote, error) {
 	writeQueueChan := make(chan *bytes.Buffer, 1000)
-	peerWriter := peer.NewWriter(conn, protocol.TestNet, s.eventBus)
+	peerWriter := peer.NewWriter(conn, s.magic, s.shared.Broadcaster)
 
-	if err := peerWriter.Connect(); err != nil {
-		log.WithFields(log.Fields{
-			"process": "server",
-			"error":   err,
-		}).Warnln("problem performing handshake")
-		return
+	if err := peerWriter.Connect(s.identity, s.bestHeight()); err != nil {
+		return nil, err
 	}
 	log.WithFields(log.Fields{
 		"process": "server",
@@ -139,18 +229,46 @@ func (s *Server) OnConnection(conn net.Conn, addr string) {
 	}).Debugln("connection established")
 
 	exitChan := make(chan struct{}, 1)
-	peerReader, err := peer.NewReader(conn, protocol.TestNet, s.dupeMap, s.eventBus, s.rpcBus, s.counter, writeQueueChan, exitChan)
+	peerReader, err := peer.NewReader(conn, s.magic, s.dupeMap, s.eventBus, s.rpcBus, s.shared, writeQueueChan, exitChan)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	peerReader.ShareSession(peerWriter.Connection)
 
 	go peerReader.ReadLoop()
 	go peerWriter.Serve(writeQueueChan, exitChan)
+	return peerWriter.Remote(), nil
+}
+
+// bestHeight returns the height of the local chain tip, which is advertised to
+// the peers during the handshake
+func (s *Server) bestHeight() uint64 {
+	r, err := s.rpcBus.Call(wire.GetLastBlock, wire.NewRequest(bytes.Buffer{}, 2))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "server",
+			"error":   err,
+		}).Warnln("could not retrieve the chain tip")
+		return 0
+	}
+
+	blk := block.NewBlock()
+	if err := blk.Decode(&r); err != nil {
+		log.WithFields(log.Fields{
+			"process": "server",
+			"error":   err,
+		}).Warnln("could not decode the chain tip")
+		return 0
+	}
+
+	return blk.Header.Height
 }
 
-// Close the chain and the connections created through the RPC bus
+// Close the peer connections, the chain and the connections created through
+// the RPC bus
 func (s *Server) Close() {
-	// TODO: disconnect peers
+	s.peerMgr.Close()
+	s.addrMgr.Close()
 	s.chain.Close()
 	s.rpcBus.Close()
 }
@@ -34,6 +34,12 @@ var CLICommands = map[string]func([]string, wire.EventBroker, *wire.RPCBus){
 	"bid":                 sendBidCMD,
 	"startprovisioner":    startProvisioner,
 	"startblockgenerator": startBlockGenerator,
+	"mempoolinfo":         mempoolInfoCMD,
+	"mempoolentries":      mempoolEntriesCMD,
+	"mempoolhistogram":    mempoolHistogramCMD,
+	"bans":                listBansCMD,
+	"ban":                 banCMD,
+	"unban":               unbanCMD,
 	"exit":                stopNode,
 	"quit":                stopNode,
 }
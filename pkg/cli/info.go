@@ -11,15 +11,27 @@ var commandInfo = map[string]string{
 		Loads the encrypted wallet file from a hex seed.`,
 	"balance": `Usage: balance
 		Prints the balance of the loaded wallet. Make sure to use the sync command first.`,
-	"transfer": `Usage: transfer [amount] [address] [password]
-		Send DUSK to a given address. Make sure to use the sync command first.`,
-	"stake": `Usage: stake [amount] [locktime] [password]
-		Stake a given amount of DUSK, to allow participation as a provisioner in consensus. Make sure to use the sync command first.`,
-	"bid": `Usage: bid [amount] [locktime] [password]
-		Bid a given amount of DUSK, to allow participation as a block generator in consensus. Make sure to use the sync command first.`,
+	"transfer": `Usage: transfer [amount] [address] [password] [fee]
+		Send DUSK to a given address. Make sure to use the sync command first. The fee is expressed in atomic units. When omitted, the node suggests a fee for the size of the transaction, based on the recent blocks and the mempool.`,
+	"stake": `Usage: stake [amount] [locktime] [password] [fee]
+		Stake a given amount of DUSK, to allow participation as a provisioner in consensus. Make sure to use the sync command first. The fee is expressed in atomic units. When omitted, the node suggests a fee for the size of the transaction.`,
+	"bid": `Usage: bid [amount] [locktime] [password] [fee]
+		Bid a given amount of DUSK, to allow participation as a block generator in consensus. Make sure to use the sync command first. The fee is expressed in atomic units. When omitted, the node suggests a fee for the size of the transaction.`,
 	"startprovisioner": `Send a signal to the connected DUSK node to start participating in consensus as a provisioner.`,
 	"startblockgenerator": `Usage: startblockgenerator [bidtxhash]
 		Send a signal to the connected DUSK node to start participating in consensus as a block generator. Specified bid tx must be included in a block before trying to start the block generation component.`,
+	"mempoolinfo": `Usage: mempoolinfo
+		Prints the number of transactions in the mempool, their overall size, the lowest fee rate and the age of the oldest entry.`,
+	"mempoolentries": `Usage: mempoolentries [txid]
+		Prints the fee, size, fee rate, and received/verified times of the transactions in the mempool. When adding a txid, shows only that transaction.`,
+	"mempoolhistogram": `Usage: mempoolhistogram
+		Prints the transactions in the mempool, grouped by fee rate (per kB).`,
+	"bans": `Usage: bans
+		Prints the banned IPs and peer IDs, with the expiry and the reason of the ban.`,
+	"ban": `Usage: ban [ip|peer id] [seconds] [reason]
+		Disconnects and bans an IP or a peer ID. Without a duration, the configured ban duration is used.`,
+	"unban": `Usage: unban [ip|peer id]
+		Lifts the ban of an IP or a peer ID.`,
 	"showlogs":  "Close the shell and show the internal logs on the terminal. Press enter to return to the shell.",
 	"exit/quit": `Shut down the node and close the console`,
 }
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/mempool"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+)
+
+func mempoolInfoCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
+	info, err := getMempoolInfo(rpcBus)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "error fetching mempool info: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "Transactions: %d\n", info.Count)
+	fmt.Fprintf(os.Stdout, "In stem phase: %d\n", info.Stem)
+	fmt.Fprintf(os.Stdout, "Size: %d bytes\n", info.Bytes)
+	fmt.Fprintf(os.Stdout, "Min fee rate: %d per kB\n", info.MinFeeRate)
+	fmt.Fprintf(os.Stdout, "Oldest entry age: %v\n", time.Duration(info.OldestAge)*time.Second)
+}
+
+func mempoolHistogramCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
+	info, err := getMempoolInfo(rpcBus)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "error fetching mempool info: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%12s %8s %10s\n", "fee rate", "txs", "bytes")
+	for _, b := range info.Histogram {
+		fmt.Fprintf(os.Stdout, "%11d+ %8d %10d\n", b.MinFeeRate, b.Count, b.Bytes)
+	}
+}
+
+func mempoolEntriesCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
+	var txID []byte
+	if len(args) > 0 && args[0] != "" {
+		var err error
+		txID, err = hex.DecodeString(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "error attempting to decode txid: %v\n", err)
+			return
+		}
+	}
+
+	r, err := rpcBus.Call(wire.GetMempoolEntries, wire.NewRequest(*bytes.NewBuffer(txID), 1))
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "error fetching mempool entries: %v\n", err)
+		return
+	}
+
+	entries, err := mempool.DecodeEntries(&r)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "error decoding mempool entries: %v\n", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stdout, "no matching transactions in the mempool\n")
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(os.Stdout, "%s type: %d stem: %t fee: %d size: %d fee rate: %d received: %s verified: %s\n",
+			e.TxID, e.Type, e.Stem, e.Fee, e.Size, e.FeeRate,
+			time.Unix(e.Received, 0).Format(time.RFC3339), time.Unix(e.Verified, 0).Format(time.RFC3339))
+	}
+}
+
+func getMempoolInfo(rpcBus *wire.RPCBus) (*mempool.Info, error) {
+	r, err := rpcBus.Call(wire.GetMempoolInfo, wire.NewRequest(bytes.Buffer{}, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &mempool.Info{}
+	if err := info.Decode(&r); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
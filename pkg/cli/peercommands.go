@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermgr"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+func listBansCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
+	r, err := rpcBus.Call(wire.GetBans, wire.NewRequest(bytes.Buffer{}, 1))
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "error fetching bans: %v\n", err)
+		return
+	}
+
+	bans, err := peermgr.DecodeBans(&r)
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "error decoding bans: %v\n", err)
+		return
+	}
+
+	if len(bans) == 0 {
+		fmt.Fprintf(os.Stdout, "no banned peers\n")
+		return
+	}
+
+	for _, b := range bans {
+		fmt.Fprintf(os.Stdout, "%s until: %s reason: %s\n", b.Target, time.Unix(b.Until, 0).Format(time.RFC3339), b.Reason)
+	}
+}
+
+func banCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
+	if args == nil || len(args) < 1 {
+		fmt.Fprintf(os.Stdout, commandInfo["ban"]+"\n")
+		return
+	}
+
+	var seconds uint64
+	if len(args) > 1 {
+		var err error
+		seconds, err = strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "%v\n", err)
+			return
+		}
+	}
+
+	reason := "banned by the node operator"
+	if len(args) > 2 {
+		reason = strings.Join(args[2:], " ")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteString(buf, args[0]); err != nil {
+		fmt.Fprintf(os.Stdout, "%v\n", err)
+		return
+	}
+
+	if err := encoding.WriteUint64(buf, binary.LittleEndian, seconds); err != nil {
+		fmt.Fprintf(os.Stdout, "%v\n", err)
+		return
+	}
+
+	if err := encoding.WriteString(buf, reason); err != nil {
+		fmt.Fprintf(os.Stdout, "%v\n", err)
+		return
+	}
+
+	if _, err := rpcBus.Call(wire.AddBan, wire.NewRequest(*buf, 1)); err != nil {
+		fmt.Fprintf(os.Stdout, "error banning %s: %v\n", args[0], err)
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%s banned\n", args[0])
+}
+
+func unbanCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
+	if args == nil || len(args) < 1 {
+		fmt.Fprintf(os.Stdout, commandInfo["unban"]+"\n")
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteString(buf, args[0]); err != nil {
+		fmt.Fprintf(os.Stdout, "%v\n", err)
+		return
+	}
+
+	if _, err := rpcBus.Call(wire.RemoveBan, wire.NewRequest(*buf, 1)); err != nil {
+		fmt.Fprintf(os.Stdout, "error unbanning %s: %v\n", args[0], err)
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%s unbanned\n", args[0])
+}
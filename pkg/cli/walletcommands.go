@@ -3,7 +3,9 @@ package cli
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 
@@ -12,9 +14,12 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/mempool"
+	wiretx "github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto/key"
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto/mlsag"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	wallet "github.com/dusk-network/dusk-blockchain/pkg/wallet"
 	walletdb "github.com/dusk-network/dusk-blockchain/pkg/wallet/database"
@@ -22,8 +27,6 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/wallet/transactions"
 )
 
-var testnet = byte(2)
-
 // cliWallet will be used to scan blocks in the background
 // when we received a topic.AcceptedBlock
 var cliWallet *wallet.Wallet
@@ -50,7 +53,7 @@ func createWalletCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPC
 		return
 	}
 
-	w, err := wallet.New(rand.Read, testnet, db, fetchDecoys, fetchInputs, password)
+	w, err := wallet.New(rand.Read, cfg.Params().AddressPrefix, db, fetchDecoys, fetchInputs, password)
 	if err != nil {
 		fmt.Fprintf(os.Stdout, "error creating wallet: %v\n", err)
 		return
@@ -106,7 +109,7 @@ func loadWallet(password string) (*wallet.Wallet, error) {
 	}
 
 	// Then load the wallet
-	w, err := wallet.LoadFromFile(testnet, db, fetchDecoys, fetchInputs, password)
+	w, err := wallet.LoadFromFile(cfg.Params().AddressPrefix, db, fetchDecoys, fetchInputs, password)
 	if err != nil {
 		db.Close()
 		return nil, err
@@ -147,43 +150,30 @@ func transferCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus)
 		return
 	}
 
-	// Create a new standard tx
-	tx, err := w.NewStandardTx(cfg.MinFee)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "error creating tx: %v\n", err)
-		return
-	}
-
-	// Send amount to address
-	tx.AddOutput(key.PublicAddress(address), amount)
+	wireTx, err := signWithFee(args, 3, rpcBus, func(fee int64) (wiretx.Transaction, error) {
+		// Create a new standard tx
+		tx, err := w.NewStandardTx(fee)
+		if err != nil {
+			return nil, err
+		}
 
-	// Sign tx
-	err = w.Sign(tx)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
-		return
-	}
+		// Send amount to address
+		tx.AddOutput(key.PublicAddress(address), amount)
 
-	// Convert wallet-tx to wireTx and encode into buffer
-	wireTx, err := tx.WireStandardTx()
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
-		return
-	}
-	buf := new(bytes.Buffer)
-	if err := wireTx.Encode(buf); err != nil {
-		fmt.Fprintf(os.Stdout, "error encoding tx: %v\n", err)
-		return
-	}
+		// Sign tx
+		if err := w.Sign(tx); err != nil {
+			return nil, err
+		}
 
-	_, err = wireTx.CalculateHash()
+		// Convert wallet-tx to wireTx
+		return tx.WireStandardTx()
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
+		fmt.Fprintf(os.Stdout, "error creating tx: %v\n", err)
 		return
 	}
-	fmt.Fprintf(os.Stdout, "hash: %s\n", hex.EncodeToString(wireTx.TxID))
 
-	publisher.Publish(string(topics.Tx), buf)
+	publishTx(publisher, wireTx)
 }
 
 func createFromSeedCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
@@ -231,7 +221,7 @@ func createFromSeed(seedBytes []byte, password string) (*wallet.Wallet, error) {
 	}
 
 	// Then load the wallet
-	w, err := wallet.LoadFromSeed(seedBytes, testnet, db, fetchDecoys, fetchInputs, password)
+	w, err := wallet.LoadFromSeed(seedBytes, cfg.Params().AddressPrefix, db, fetchDecoys, fetchInputs, password)
 	if err != nil {
 		return nil, err
 	}
@@ -276,40 +266,27 @@ func sendStakeCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus
 		return
 	}
 
-	// Create a new stake tx
-	tx, err := w.NewStakeTx(cfg.MinFee, lockTime, amount)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "error creating tx: %v\n", err)
-		return
-	}
-
-	// Sign tx
-	err = w.Sign(tx)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
-		return
-	}
+	wireTx, err := signWithFee(args, 3, rpcBus, func(fee int64) (wiretx.Transaction, error) {
+		// Create a new stake tx
+		tx, err := w.NewStakeTx(fee, lockTime, amount)
+		if err != nil {
+			return nil, err
+		}
 
-	// Convert wallet-tx to wireTx and encode into buffer
-	wireTx, err := tx.WireStakeTx()
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
-		return
-	}
-	buf := new(bytes.Buffer)
-	if err := wireTx.Encode(buf); err != nil {
-		fmt.Fprintf(os.Stdout, "error encoding tx: %v\n", err)
-		return
-	}
+		// Sign tx
+		if err := w.Sign(tx); err != nil {
+			return nil, err
+		}
 
-	_, err = wireTx.CalculateHash()
+		// Convert wallet-tx to wireTx
+		return tx.WireStakeTx()
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
+		fmt.Fprintf(os.Stdout, "error creating tx: %v\n", err)
 		return
 	}
-	fmt.Fprintf(os.Stdout, "hash: %s\n", hex.EncodeToString(wireTx.TxID))
 
-	publisher.Publish(string(topics.Tx), buf)
+	publishTx(publisher, wireTx)
 }
 
 func sendBidCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus) {
@@ -345,40 +322,27 @@ func sendBidCMD(args []string, publisher wire.EventBroker, rpcBus *wire.RPCBus)
 		return
 	}
 
-	// Create a new bid tx
-	tx, err := w.NewBidTx(cfg.MinFee, lockTime, amount)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "error creating tx: %v\n", err)
-		return
-	}
-
-	// Sign tx
-	err = w.Sign(tx)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
-		return
-	}
+	wireTx, err := signWithFee(args, 3, rpcBus, func(fee int64) (wiretx.Transaction, error) {
+		// Create a new bid tx
+		tx, err := w.NewBidTx(fee, lockTime, amount)
+		if err != nil {
+			return nil, err
+		}
 
-	// Convert wallet-tx to wireTx and encode into buffer
-	wireTx, err := tx.WireBid()
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
-		return
-	}
-	buf := new(bytes.Buffer)
-	if err := wireTx.Encode(buf); err != nil {
-		fmt.Fprintf(os.Stdout, "error encoding tx: %v\n", err)
-		return
-	}
+		// Sign tx
+		if err := w.Sign(tx); err != nil {
+			return nil, err
+		}
 
-	_, err = wireTx.CalculateHash()
+		// Convert wallet-tx to wireTx
+		return tx.WireBid()
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
+		fmt.Fprintf(os.Stdout, "error creating tx: %v\n", err)
 		return
 	}
-	fmt.Fprintf(os.Stdout, "hash: %s\n", hex.EncodeToString(wireTx.TxID))
 
-	publisher.Publish(string(topics.Tx), buf)
+	publishTx(publisher, wireTx)
 }
 
 func syncWallet() error {
@@ -516,3 +480,90 @@ func fetchInputs(netPrefix byte, db *walletdb.DB, totalAmount int64, key *key.Ke
 	}
 	return db.FetchInputs(privSpend.Bytes(), totalAmount)
 }
+
+// maxFeeRounds is the amount of times a tx is built, at most, for its fee to
+// cover its size
+const maxFeeRounds = 5
+
+// buildTx builds and signs a tx paying the given fee, and converts it to its
+// wire format.
+type buildTx func(fee int64) (wiretx.Transaction, error)
+
+// signWithFee builds a tx paying the fee given in args[feeArg], or, when it is
+// omitted, the fee estimated by the node for the encoded size of the tx. As
+// the size of a tx hardly depends on its fee, the tx is built again with the
+// fee required by its last size, until that fee covers it.
+func signWithFee(args []string, feeArg int, rpcBus *wire.RPCBus, build buildTx) (wiretx.Transaction, error) {
+	if len(args) > feeArg {
+		fee, err := stringToInt64(args[feeArg])
+		if err != nil {
+			return nil, err
+		}
+
+		return build(fee)
+	}
+
+	estimate, err := estimateFee(rpcBus)
+	if err != nil {
+		return nil, err
+	}
+
+	// start from the fee of a tx of average size
+	fee := int64(estimate.Fee)
+	for i := 0; i < maxFeeRounds; i++ {
+		tx, err := build(fee)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := new(bytes.Buffer)
+		if err := tx.Encode(buf); err != nil {
+			return nil, err
+		}
+
+		required := int64(estimate.FeeFor(uint64(buf.Len())))
+		if required <= fee {
+			return tx, nil
+		}
+
+		fee = required
+	}
+
+	return nil, errors.New("could not settle the fee of the tx")
+}
+
+// publishTx prints the hash of a tx, and relays it through the stem
+func publishTx(publisher wire.EventBroker, tx wiretx.Transaction) {
+	buf := new(bytes.Buffer)
+	if err := tx.Encode(buf); err != nil {
+		fmt.Fprintf(os.Stdout, "error encoding tx: %v\n", err)
+		return
+	}
+
+	txID, err := tx.CalculateHash()
+	if err != nil {
+		fmt.Fprintf(os.Stdout, "%s\n", err.Error())
+		return
+	}
+	fmt.Fprintf(os.Stdout, "hash: %s\n", hex.EncodeToString(txID))
+
+	publisher.Publish(string(topics.StemTx), buf)
+}
+
+// estimateFee asks the mempool for the fee rate a tx should pay to be included
+// within mempool.DefaultEstimateTarget blocks.
+func estimateFee(rpcBus *wire.RPCBus) (mempool.FeeEstimate, error) {
+	estimate := mempool.FeeEstimate{}
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint32(buf, binary.LittleEndian, mempool.DefaultEstimateTarget); err != nil {
+		return estimate, err
+	}
+
+	r, err := rpcBus.Call(wire.EstimateFee, wire.NewRequest(*buf, 2))
+	if err != nil {
+		return estimate, err
+	}
+
+	err = estimate.Decode(&r)
+	return estimate, err
+}
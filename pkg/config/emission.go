@@ -0,0 +1,52 @@
+package config
+
+// EmissionSchedule defines the amount of DUSK minted by each block. The reward
+// starts at InitialReward with the genesis block, and is halved every
+// HalvingInterval blocks.
+type EmissionSchedule struct {
+	InitialReward   uint64
+	HalvingInterval uint64
+}
+
+// yearlyHalving halves the reward roughly once a year, with a 20 seconds
+// block time
+var yearlyHalving = EmissionSchedule{InitialReward: GeneratorReward, HalvingInterval: 1576800}
+
+// Emission returns the emission schedule of the configured network. Unknown
+// networks fall back to the testnet schedule, as for the other parameters.
+func Emission() EmissionSchedule {
+	return Params().Emission
+}
+
+// Reward returns the amount of DUSK minted by the block at the given height.
+// It does not include the fees of the block txs.
+func (e EmissionSchedule) Reward(height uint64) uint64 {
+	halvings := height / e.HalvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+
+	return e.InitialReward >> halvings
+}
+
+// Supply returns the overall amount of DUSK minted by the blocks up to, and
+// including, the one at the given height.
+func (e EmissionSchedule) Supply(height uint64) uint64 {
+	var supply uint64
+	var epoch uint64
+	for ; epoch < 64; epoch++ {
+		start := epoch * e.HalvingInterval
+		if start > height {
+			break
+		}
+
+		blocks := e.HalvingInterval
+		if height-start < blocks {
+			blocks = height - start + 1
+		}
+
+		supply += blocks * (e.InitialReward >> epoch)
+	}
+
+	return supply
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmissionSchedule(t *testing.T) {
+	e := EmissionSchedule{InitialReward: 100, HalvingInterval: 10}
+
+	assert.Equal(t, uint64(100), e.Reward(0))
+	assert.Equal(t, uint64(100), e.Reward(9))
+	assert.Equal(t, uint64(50), e.Reward(10))
+	assert.Equal(t, uint64(25), e.Reward(25))
+	assert.Equal(t, uint64(0), e.Reward(10*64))
+
+	// Supply should always match the sum of the rewards
+	var supply uint64
+	for h := uint64(0); h < 100; h++ {
+		supply += e.Reward(h)
+		assert.Equal(t, supply, e.Supply(h))
+	}
+}
+
+// Every network should have its own emission schedule.
+func TestNetworkEmission(t *testing.T) {
+	for name, params := range networks {
+		assert.NotZero(t, params.Emission.InitialReward, name)
+		assert.NotZero(t, params.Emission.HalvingInterval, name)
+	}
+
+	r := Registry{}
+	r.General.Network = "devnet"
+	Mock(&r)
+	defer Reset()
+	assert.Equal(t, networks["devnet"].Emission, Emission())
+}
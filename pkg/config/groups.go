@@ -2,6 +2,9 @@ package config
 
 type generalConfiguration struct {
 	Network string
+	// file storing the hex encoded genesis block, replacing the built-in
+	// genesis block of the network
+	Genesis string
 }
 
 type loggerConfiguration struct {
@@ -18,9 +21,45 @@ type logMonitorConfiguration struct {
 }
 
 type networkConfiguration struct {
-	Seeder  seedersConfiguration
-	Monitor monitorConfiguration
-	Port    string
+	Seeder    seedersConfiguration
+	Monitor   monitorConfiguration
+	Peers     peersConfiguration
+	Broadcast broadcastConfiguration
+	Limits    limitsConfiguration
+	Port      string
+	// file storing the identity key of the node
+	Identity string
+}
+
+// propagation of the gossip messages
+type broadcastConfiguration struct {
+	// either "flood" or "kadcast"
+	Overlay string
+	// amount of peers a message is delegated to, per kadcast bucket
+	Redundancy int
+}
+
+// bandwidth and rate limits of the messages received from the peers
+type limitsConfiguration struct {
+	// bytes per second received from all the peers, 0 for no limit
+	GlobalBandwidth int
+	// bytes per second received from each peer, 0 for no limit
+	PeerBandwidth int
+	// messages per second accepted from each peer, by topic. Overrides the
+	// default rate of a topic, 0 for no limit
+	TopicRates map[string]float64
+}
+
+// peer manager connection limits
+type peersConfiguration struct {
+	TargetOutbound  int
+	OverlayOutbound int
+	MaxInbound      int
+	MaxPerIP        int
+	AddrBook        string
+	BanThreshold    uint32
+	BanDuration     int
+	BanList         string
 }
 
 type monitorConfiguration struct {
@@ -112,6 +112,7 @@ func (r *Registry) init() error {
 	}
 
 	r.UsedConfigFile = viper.ConfigFileUsed()
+	r.applyNetworkDefaults()
 
 	return nil
 }
@@ -148,7 +149,7 @@ func loadFlags() (string, error) {
 func defineFlags() {
 	_ = pflag.StringP("logger.level", "l", "", "override logger.level settings in config file")
 	_ = pflag.StringP("general.network", "n", "testnet", "override general.network settings in config file")
-	_ = pflag.StringP("network.port", "p", "7000", "port for the node to bind on")
+	_ = pflag.StringP("network.port", "p", "", "port for the node to bind on, defaults to the port of the network")
 	_ = pflag.StringP("logger.output", "o", "dusk", "specifies the log output")
 	_ = pflag.StringP("database.dir", "b", "chain", "sets the blockchain database directory")
 	_ = pflag.StringP("wallet.file", "w", "wallet.dat", "sets the wallet file to use")
@@ -71,6 +71,42 @@ func TestSupportedFlags(t *testing.T) {
 	}
 }
 
+// TestNetworkDefaults ensures the settings left empty are filled with the
+// defaults of the configured network
+func TestNetworkDefaults(t *testing.T) {
+
+	Reset()
+
+	os.Args = append(os.Args, defaultDuskConfig)
+	if err := Load(); err != nil {
+		t.Errorf("Failed parse: %v", err)
+	}
+
+	if Get().Network.Port != "7000" {
+		t.Errorf("Invalid testnet port %s", Get().Network.Port)
+	}
+
+	if len(Get().Network.Seeder.Addresses) != 1 {
+		t.Errorf("Invalid testnet seeders %v", Get().Network.Seeder.Addresses)
+	}
+
+	Reset()
+
+	os.Args = append(os.Args, defaultDuskConfig)
+	os.Args = append(os.Args, "--general.network=devnet")
+	if err := Load(); err != nil {
+		t.Errorf("Failed parse: %v", err)
+	}
+
+	if Get().Network.Port != "7200" {
+		t.Errorf("Invalid devnet port %s", Get().Network.Port)
+	}
+
+	if Params().AddressPrefix != 3 {
+		t.Errorf("Invalid devnet address prefix %d", Params().AddressPrefix)
+	}
+}
+
 // TestSupportedEnv
 //
 // ensures all supported ENV variables are properly bound and they overwrite the
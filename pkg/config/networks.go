@@ -0,0 +1,73 @@
+package config
+
+import "strings"
+
+// NetworkParams holds the settings which differ from one Dusk network to the
+// other
+type NetworkParams struct {
+	// GenesisBlob is the hex encoded genesis block of the network. It can be
+	// replaced with the general.genesis setting.
+	GenesisBlob string
+	// Port is the default port of the peer network
+	Port string
+	// Seeders are the default voucher seeders of the network
+	Seeders []string
+	// AddressPrefix is the network byte of the public addresses, so that the
+	// addresses of a network can not be used on another one
+	AddressPrefix byte
+	// Emission is the schedule of the DUSK minted by the blocks
+	Emission EmissionSchedule
+}
+
+// networks holds the parameters of each network
+var networks = map[string]NetworkParams{
+	"mainnet": {
+		// the genesis block of mainnet is not released yet
+		Port:          "7100",
+		AddressPrefix: 1,
+		Emission:      yearlyHalving,
+	},
+	"testnet": {
+		GenesisBlob:   TestNetGenesisBlob,
+		Port:          "7000",
+		Seeders:       []string{"voucher.dusk.network:8081"},
+		AddressPrefix: 2,
+		Emission:      yearlyHalving,
+	},
+	"devnet": {
+		// development networks are local and short-lived, they share the
+		// testnet genesis block unless general.genesis is set
+		GenesisBlob:   TestNetGenesisBlob,
+		Port:          "7200",
+		AddressPrefix: 3,
+		// halvings happen within a day, so that they can be tested
+		Emission: EmissionSchedule{InitialReward: GeneratorReward, HalvingInterval: 4320},
+	},
+}
+
+// Params returns the parameters of the configured network. Unknown networks
+// fall back to the testnet parameters.
+func Params() NetworkParams {
+	if p, ok := networks[strings.ToLower(Get().General.Network)]; ok {
+		return p
+	}
+
+	return networks["testnet"]
+}
+
+// applyNetworkDefaults fills the settings left empty with the defaults of the
+// configured network
+func (r *Registry) applyNetworkDefaults() {
+	p, ok := networks[strings.ToLower(r.General.Network)]
+	if !ok {
+		return
+	}
+
+	if r.Network.Port == "" {
+		r.Network.Port = p.Port
+	}
+
+	if len(r.Network.Seeder.Addresses) == 0 {
+		r.Network.Seeder.Addresses = p.Seeders
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 )
 
+// CertificateSize size of an encoded certificate in bytes
+const CertificateSize = 33 + 33 + 1 + 8 + 8
+
 // Certificate defines a block certificate made as a result from the consensus.
 type Certificate struct {
 	StepOneBatchedSig []byte // Batched BLS signature of the block reduction phase (33 bytes)
@@ -14,6 +14,8 @@ const (
 	HeaderHashSize = 32
 	// HeightSize size of a block height field in bytes
 	HeightSize = 8
+	// HeaderSize size of an encoded block header in bytes
+	HeaderSize = 1 + HeightSize + 8 + HeaderHashSize + 33 + HeaderHashSize + CertificateSize + HeaderHashSize
 )
 
 // Header defines a block header on a Dusk block.
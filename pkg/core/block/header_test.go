@@ -22,6 +22,7 @@ func TestEncodeDecodeHeader(t *testing.T) {
 	buf := new(bytes.Buffer)
 	err = hdr.Encode(buf)
 	assert.Nil(err)
+	assert.Equal(block.HeaderSize, buf.Len())
 
 	// Decode buffer into a header struct
 	decHdr := &block.Header{}
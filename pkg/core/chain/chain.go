@@ -112,6 +112,24 @@ func (c *Chain) Listen() {
 			}
 
 			r.RespChan <- bytes.Buffer{}
+
+		case r := <-wire.GetBlockLocatorChan:
+			buf, err := c.blockLocator()
+			if err != nil {
+				r.ErrChan <- err
+				continue
+			}
+
+			r.RespChan <- *buf
+
+		case r := <-wire.VerifyHeadersChan:
+			buf, err := c.verifyHeaders(&r.Params)
+			if err != nil {
+				r.ErrChan <- err
+				continue
+			}
+
+			r.RespChan <- *buf
 		}
 	}
 }
@@ -192,7 +210,46 @@ func (c *Chain) onAcceptBlock(m *bytes.Buffer) error {
 		return err
 	}
 
-	return c.AcceptBlock(*blk)
+	// the hash sent along with the block is not trusted, as the rejection is
+	// routed to the peer which relayed the block by its hash
+	if err := blk.SetHash(); err != nil {
+		return err
+	}
+
+	if err := c.AcceptBlock(*blk); err != nil {
+		// Let the peer which relayed this block know about the rejection
+		c.publishReject(peermsg.NewReject(topics.Block, rejectCode(err), err.Error(), blk.Header.Hash))
+		return err
+	}
+
+	return nil
+}
+
+// rejectCode maps an error of AcceptBlock onto a peermsg.RejectCode. Only the
+// blocks breaking the consensus rules are rejected as invalid, as the other
+// errors are not the fault of the peer which relayed the block.
+func rejectCode(err error) peermsg.RejectCode {
+	switch {
+	case err == verifiers.ErrBlockExists:
+		return peermsg.RejectDuplicate
+	case err == verifiers.ErrPrevBlockMismatch, err == verifiers.ErrHeightMismatch:
+		return peermsg.RejectOrphan
+	case verifiers.IsRuleError(err):
+		return peermsg.RejectInvalid
+	}
+
+	return peermsg.RejectInternal
+}
+
+// publishReject notifies the other subsystems about a rejected block
+func (c *Chain) publishReject(reject *peermsg.Reject) {
+	buf := new(bytes.Buffer)
+	if err := reject.Encode(buf); err != nil {
+		log.Errorf("encoding reject: %s", err.Error())
+		return
+	}
+
+	c.eventBus.Publish(string(topics.Reject), buf)
 }
 
 // AcceptBlock will accept a block if
@@ -349,6 +406,33 @@ func (c *Chain) verifyCandidateBlock(hash []byte) error {
 	return verifiers.CheckBlock(c.db, c.prevBlock, *candidate)
 }
 
+// verifyHeaders checks the certificates of a chain of headers, and returns the
+// amount of leading headers with a valid one. The certificates can only be
+// checked against the current committee, so the headers far above our tip may
+// fail even if they are valid.
+func (c *Chain) verifyHeaders(m *bytes.Buffer) (*bytes.Buffer, error) {
+	headers := &peermsg.Headers{}
+	if err := headers.Decode(m); err != nil {
+		return nil, err
+	}
+
+	var valid uint64
+	for _, header := range headers.Headers {
+		if err := verifiers.CheckBlockCertificate(c.committee, block.Block{Header: header}); err != nil {
+			break
+		}
+
+		valid++
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint64(buf, binary.LittleEndian, valid); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
 func (c *Chain) addCertificate(blockHash []byte, cert *block.Certificate) {
 	candidate, err := c.fetchCandidateBlock(blockHash)
 	if err != nil {
@@ -360,7 +444,7 @@ func (c *Chain) addCertificate(blockHash []byte, cert *block.Certificate) {
 	c.AcceptBlock(*candidate)
 }
 
-// Send Inventory message to all peers
+// Send Inventory message to all peers, which do not know about the block yet
 func (c *Chain) advertiseBlock(b block.Block) error {
 	msg := &peermsg.Inv{}
 	msg.AddItem(peermsg.InvTypeBlock, b.Header.Hash)
@@ -370,11 +454,6 @@ func (c *Chain) advertiseBlock(b block.Block) error {
 		panic(err)
 	}
 
-	withTopic, err := wire.AddTopic(buf, topics.Inv)
-	if err != nil {
-		return err
-	}
-
-	c.eventBus.Stream(string(topics.Gossip), withTopic)
+	c.eventBus.Publish(string(topics.Inv), buf)
 	return nil
 }
@@ -3,6 +3,7 @@ package chain
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"testing"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
@@ -15,6 +16,8 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
 	_ "github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/stretchr/testify/assert"
@@ -52,6 +55,14 @@ func TestDemoSaveFunctionality(t *testing.T) {
 }
 */
 
+func TestLocatorHeights(t *testing.T) {
+	assert.Equal(t, []uint64{0}, locatorHeights(0))
+	assert.Equal(t, []uint64{5, 4, 3, 2, 1, 0}, locatorHeights(5))
+
+	heights := locatorHeights(1000)
+	assert.Equal(t, []uint64{1000, 999, 998, 997, 996, 995, 994, 993, 992, 991, 989, 985, 977, 961, 929, 865, 737, 481, 0}, heights)
+}
+
 func createMockedCertificate(hash []byte, round uint64, keys []user.Keys) *block.Certificate {
 	votes := agreement.GenVotes(hash, round, 1, keys)
 	return &block.Certificate{
@@ -134,3 +145,13 @@ func publishNewStake(stake uint64, eb *wire.EventBus, startHeight, endHeight uin
 
 	eb.Publish(msg.NewProvisionerTopic, buffer)
 }
+
+// Only the blocks breaking the consensus rules should be rejected as invalid,
+// as the rejection bans the peer which relayed them.
+func TestRejectCode(t *testing.T) {
+	assert.Equal(t, peermsg.RejectDuplicate, rejectCode(verifiers.ErrBlockExists))
+	assert.Equal(t, peermsg.RejectOrphan, rejectCode(verifiers.ErrPrevBlockMismatch))
+	assert.Equal(t, peermsg.RejectOrphan, rejectCode(verifiers.ErrHeightMismatch))
+	assert.Equal(t, peermsg.RejectInvalid, rejectCode(verifiers.RuleError{Err: verifiers.ErrBlockTooLarge}))
+	assert.Equal(t, peermsg.RejectInternal, rejectCode(errors.New("could not store the block")))
+}
@@ -0,0 +1,68 @@
+package chain
+
+import (
+	"bytes"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+)
+
+// denseLocators is the amount of consecutive blocks at the top of a locator
+const denseLocators = 10
+
+// locatorHeights returns the heights of the blocks to put in the locator of a
+// chain whose tip is at the given height. The first denseLocators blocks
+// below the tip are all included, and the steps double from there on. The
+// genesis block always ends the locator.
+func locatorHeights(tip uint64) []uint64 {
+	heights := make([]uint64, 0, denseLocators+64)
+	step := uint64(1)
+	height := tip
+	for {
+		heights = append(heights, height)
+		if height == 0 {
+			return heights
+		}
+
+		if len(heights) >= denseLocators {
+			step *= 2
+		}
+
+		if height < step {
+			height = 0
+		} else {
+			height -= step
+		}
+	}
+}
+
+// blockLocator returns the locator of our chain, as a marshaled GetBlocks
+func (c *Chain) blockLocator() (*bytes.Buffer, error) {
+	c.mu.RLock()
+	tip := c.prevBlock.Header.Height
+	c.mu.RUnlock()
+
+	msg := &peermsg.GetBlocks{}
+	err := c.db.View(func(t database.Transaction) error {
+		for _, height := range locatorHeights(tip) {
+			hash, err := t.FetchBlockHashByHeight(height)
+			if err != nil {
+				return err
+			}
+
+			msg.Locators = append(msg.Locators, hash)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := msg.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
@@ -2,6 +2,7 @@ package generation
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto/key"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
@@ -37,7 +39,7 @@ func newBlockGenerator(genPubKey *key.PublicKey, rpcBus *wire.RPCBus) *blockGene
 }
 
 func (bg *blockGenerator) GenerateBlock(round uint64, seed, proof, score, prevBlockHash []byte) (*block.Block, error) {
-	txs, err := bg.ConstructBlockTxs(proof, score)
+	txs, err := bg.ConstructBlockTxs(round, proof, score)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +74,10 @@ func (bg *blockGenerator) GenerateBlock(round uint64, seed, proof, score, prevBl
 	return candidateBlock, nil
 }
 
-func (bg *blockGenerator) ConstructBlockTxs(proof, score []byte) ([]transactions.Transaction, error) {
+// ConstructBlockTxs builds the txs of a candidate block at the given round. The
+// coinbase tx rewards the generator with the block emission plus the fees of
+// the txs retrieved from the mempool.
+func (bg *blockGenerator) ConstructBlockTxs(round uint64, proof, score []byte) ([]transactions.Transaction, error) {
 
 	txs := make([]transactions.Transaction, 0)
 
@@ -86,7 +91,17 @@ func (bg *blockGenerator) ConstructBlockTxs(proof, score []byte) ([]transactions
 
 	// Retrieve and append the verified transactions from Mempool
 	if bg.rpcBus != nil {
-		r, err := bg.rpcBus.Call(wire.GetMempoolTxs, wire.NewRequest(bytes.Buffer{}, 10))
+		maxSize, err := maxTxsSize(coinbaseTx)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := new(bytes.Buffer)
+		if err := encoding.WriteUint32(buf, binary.LittleEndian, maxSize); err != nil {
+			return nil, err
+		}
+
+		r, err := bg.rpcBus.Call(wire.GetMempoolTxsBySize, wire.NewRequest(*buf, 10))
 		// TODO: GetVerifiedTxs should ensure once again that none of the txs have been
 		// already accepted in the the chain.
 		if err != nil {
@@ -106,11 +121,31 @@ func (bg *blockGenerator) ConstructBlockTxs(proof, score []byte) ([]transactions
 		txs = append(txs, mempoolTxs...)
 	}
 
+	// The reward can only be set once the fees are known
+	setReward(coinbaseTx, verifiers.BlockReward(round, txs))
+
 	// TODO Append Provisioners rewards
 
 	return txs, nil
 }
 
+// maxTxsSize returns the room left for the mempool txs in a candidate block,
+// after the header, the coinbase tx and the tx count are accounted for.
+func maxTxsSize(coinbaseTx transactions.Transaction) (uint32, error) {
+	buf := new(bytes.Buffer)
+	if err := coinbaseTx.Encode(buf); err != nil {
+		return 0, err
+	}
+
+	// a varint takes up to 9 bytes
+	reserved := block.HeaderSize + buf.Len() + 9
+	if reserved >= config.MaxBlockSize {
+		return 0, nil
+	}
+
+	return uint32(config.MaxBlockSize - reserved), nil
+}
+
 // constructCoinbaseTx forges the transactions to reward the block generator
 func (c *blockGenerator) constructCoinbaseTx(rewardReceiver *key.PublicKey, proof []byte, score []byte) (*transactions.Coinbase, error) {
 	// The rewards for both the Generator and the Provisioners are disclosed.
@@ -139,13 +174,10 @@ func (c *blockGenerator) constructCoinbaseTx(rewardReceiver *key.PublicKey, proo
 	const coinbaseIndex = 0
 	P := rewardReceiver.StealthAddress(r, coinbaseIndex).P
 
-	// Disclose  reward
-	var reward ristretto.Scalar
-	reward.SetBigInt(big.NewInt(int64(config.GeneratorReward)))
-
 	output := &transactions.Output{
-		// EncryptedAmount field in coinbase tx represents the reward
-		EncryptedAmount: reward.Bytes(),
+		// EncryptedAmount field in coinbase tx represents the reward, which
+		// is disclosed later on by setReward
+		EncryptedAmount: make([]byte, 32),
 		EncryptedMask:   make([]byte, 1),
 		Commitment:      make([]byte, 32),
 		DestKey:         P.Bytes(),
@@ -158,3 +190,10 @@ func (c *blockGenerator) constructCoinbaseTx(rewardReceiver *key.PublicKey, proo
 
 	return tx, nil
 }
+
+// setReward discloses the reward of a coinbase tx
+func setReward(tx *transactions.Coinbase, amount uint64) {
+	var reward ristretto.Scalar
+	reward.SetBigInt(new(big.Int).SetUint64(amount))
+	tx.Rewards[0].EncryptedAmount = reward.Bytes()
+}
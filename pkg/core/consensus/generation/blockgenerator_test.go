@@ -15,6 +15,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/mempool"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto"
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto/key"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
@@ -90,6 +91,18 @@ func TestGenerateBlock(t *testing.T) {
 	if !bytes.Equal(coinbaseTx.Score, score) {
 		t.Fatalf("expecting candidate block to store the proof value properly")
 	}
+
+	// The coinbase should reward the emission plus the fees of the mempool
+	// txs
+	var fees uint64
+	for _, tx := range candidateBlock.Txs[1:] {
+		fees += tx.StandardTX().Fee
+	}
+
+	reward := cfg.Emission().Reward(round) + fees
+	if err := verifiers.VerifyCoinbase(0, coinbaseTx, reward); err != nil {
+		t.Fatalf("expecting the coinbase tx to include a reward of %d: %s", reward, err.Error())
+	}
 }
 
 func publishRandomTxs(t *testing.T, h *harness) (int, error) {
@@ -0,0 +1,56 @@
+package mempool
+
+import (
+	"sort"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+)
+
+// typeQuotas limits the amount of txs of a given type that are included in a
+// candidate block. Types which are not listed are not limited.
+var typeQuotas = map[transactions.TxType]int{
+	transactions.StakeType: config.MaxStakeTxsPerBlock,
+	transactions.BidType:   config.MaxBidTxsPerBlock,
+}
+
+// assemble selects the verified txs to be included in a candidate block. Txs
+// paying the highest fee rate are selected first, until either the pool is
+// exhausted or no more txs fit in maxSize bytes. Txs of the same fee rate are
+// selected in the order they were received.
+func assemble(p Pool, maxSize uint64) []transactions.Transaction {
+	descs := make([]TxDesc, 0, p.Len())
+	_ = p.Range(func(k key, t TxDesc) error {
+		descs = append(descs, t)
+		return nil
+	})
+
+	sort.Slice(descs, func(i, j int) bool {
+		ri, rj := descs[i].feeRate(), descs[j].feeRate()
+		if ri != rj {
+			return ri > rj
+		}
+		return descs[i].received.Before(descs[j].received)
+	})
+
+	txs := make([]transactions.Transaction, 0)
+	counts := make(map[transactions.TxType]int)
+	var size uint64
+	for _, t := range descs {
+		// a smaller tx with a lower fee rate may still fit
+		if size+t.size > maxSize {
+			continue
+		}
+
+		txType := t.tx.Type()
+		if quota, ok := typeQuotas[txType]; ok && counts[txType] >= quota {
+			continue
+		}
+
+		txs = append(txs, t.tx)
+		counts[txType]++
+		size += t.size
+	}
+
+	return txs
+}
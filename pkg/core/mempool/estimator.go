@@ -0,0 +1,208 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+const (
+	// number of accepted blocks the estimator keeps track of
+	estimatorHistory = 100
+	// a block is considered full when its txs take up this percentage of the
+	// max block size
+	fullBlockPercentage = 90
+	// rough size of a tx, used until an actual tx has been seen in a block
+	defaultTxSize = 2000
+	// DefaultEstimateTarget is the confirmation target (in blocks) used when
+	// none is specified
+	DefaultEstimateTarget = 2
+	// MaxEstimateTarget is the highest confirmation target (in blocks) a fee
+	// can be estimated for
+	MaxEstimateTarget = 100
+)
+
+// blockStats records the fee rates paid in an accepted block
+type blockStats struct {
+	minFeeRate uint64
+	full       bool
+}
+
+// feeEstimator suggests the fee rate a tx should pay to be included in a block
+// within a target amount of blocks. It combines the fee rates paid in the
+// latest accepted blocks with the fee rates of the txs waiting in the mempool.
+type feeEstimator struct {
+	history []blockStats
+
+	// running average of the size of the txs seen in the accepted blocks
+	txsCount uint64
+	txsSize  uint64
+}
+
+// FeeEstimate is the suggestion of the estimator for a given target.
+type FeeEstimate struct {
+	// Target is the confirmation delay, in blocks
+	Target uint32 `json:"target"`
+	// FeeRate is expressed in atomic units per kB
+	FeeRate uint64 `json:"feeRate"`
+	// Fee is the suggested fee for a tx of average size. It is never lower
+	// than config.MinFee
+	Fee uint64 `json:"fee"`
+}
+
+// onBlock records the fee rates paid by the txs of an accepted block
+func (e *feeEstimator) onBlock(b block.Block) {
+	stats := blockStats{}
+	var size uint64
+	var seen bool
+	for _, tx := range b.Txs {
+		if tx.Type() == transactions.CoinbaseType {
+			continue
+		}
+
+		buf := new(bytes.Buffer)
+		if err := tx.Encode(buf); err != nil {
+			continue
+		}
+
+		txSize := uint64(buf.Len())
+		rate := feeRate(tx.StandardTX().Fee, txSize)
+		if !seen || rate < stats.minFeeRate {
+			stats.minFeeRate = rate
+			seen = true
+		}
+
+		size += txSize
+		e.txsCount++
+		e.txsSize += txSize
+	}
+
+	stats.full = size*100 >= blockCapacity()*fullBlockPercentage
+
+	e.history = append(e.history, stats)
+	if len(e.history) > estimatorHistory {
+		e.history = e.history[1:]
+	}
+}
+
+// estimate the fee rate needed by a tx to be included within target blocks,
+// given the current state of the verified pool
+func (e *feeEstimator) estimate(target uint32, p Pool) FeeEstimate {
+	if target == 0 {
+		target = 1
+	}
+
+	if target > MaxEstimateTarget {
+		target = MaxEstimateTarget
+	}
+
+	rate := e.historyRate(target)
+	if r := mempoolRate(target, p); r > rate {
+		rate = r
+	}
+
+	estimate := FeeEstimate{Target: target, FeeRate: rate}
+	estimate.Fee = estimate.FeeFor(e.averageTxSize())
+	return estimate
+}
+
+// FeeFor returns the fee a tx of the given size should pay at the estimated
+// rate. It is never lower than config.MinFee.
+func (f FeeEstimate) FeeFor(size uint64) uint64 {
+	fee := f.FeeRate * size / 1000
+	if fee < uint64(config.MinFee) {
+		fee = uint64(config.MinFee)
+	}
+
+	return fee
+}
+
+// historyRate looks at the minimum fee rates paid in the latest full blocks.
+// Blocks which are not full are ignored, as any fee rate would have been
+// accepted in them. The further the target, the lower the rate.
+func (e *feeEstimator) historyRate(target uint32) uint64 {
+	rates := make([]uint64, 0, len(e.history))
+	for _, s := range e.history {
+		if s.full {
+			rates = append(rates, s.minFeeRate)
+		}
+	}
+
+	if len(rates) == 0 {
+		return 0
+	}
+
+	// highest rates first. A target of 1 block picks the median, while
+	// further targets move towards the lowest rate
+	sort.Slice(rates, func(i, j int) bool { return rates[i] > rates[j] })
+	last := len(rates) - 1
+	return rates[last-last/(2*int(target))]
+}
+
+// mempoolRate returns the fee rate needed to outbid the txs which already fill
+// the next target blocks. If the pool does not fill them, any rate will do.
+func mempoolRate(target uint32, p Pool) uint64 {
+	descs := make([]TxDesc, 0, p.Len())
+	_ = p.Range(func(k key, t TxDesc) error {
+		descs = append(descs, t)
+		return nil
+	})
+
+	sort.Slice(descs, func(i, j int) bool { return descs[i].feeRate() > descs[j].feeRate() })
+
+	capacity := uint64(target) * blockCapacity()
+	var size uint64
+	for _, t := range descs {
+		size += t.size
+		if size > capacity {
+			return t.feeRate() + 1
+		}
+	}
+
+	return 0
+}
+
+func (e *feeEstimator) averageTxSize() uint64 {
+	if e.txsCount == 0 {
+		return defaultTxSize
+	}
+
+	return e.txsSize / e.txsCount
+}
+
+// blockCapacity is the room for txs in a block
+func blockCapacity() uint64 {
+	return uint64(config.MaxBlockSize - block.HeaderSize)
+}
+
+// Encode a FeeEstimate struct and write it to w.
+func (f FeeEstimate) Encode(w io.Writer) error {
+	if err := encoding.WriteUint32(w, binary.LittleEndian, f.Target); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint64(w, binary.LittleEndian, f.FeeRate); err != nil {
+		return err
+	}
+
+	return encoding.WriteUint64(w, binary.LittleEndian, f.Fee)
+}
+
+// Decode a FeeEstimate struct from r.
+func (f *FeeEstimate) Decode(r io.Reader) error {
+	if err := encoding.ReadUint32(r, binary.LittleEndian, &f.Target); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadUint64(r, binary.LittleEndian, &f.FeeRate); err != nil {
+		return err
+	}
+
+	return encoding.ReadUint64(r, binary.LittleEndian, &f.Fee)
+}
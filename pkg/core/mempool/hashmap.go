@@ -2,7 +2,6 @@ package mempool
 
 import (
 	"fmt"
-	"unsafe"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
 )
@@ -50,7 +49,7 @@ func (m *HashMap) Put(t TxDesc) error {
 	copy(k[:], txID)
 	m.data[k] = t
 
-	m.txsSize += uint64(unsafe.Sizeof(t.tx))
+	m.txsSize += t.size
 
 	// store all tx key images, if provided
 	for i, input := range t.tx.StandardTX().Inputs {
@@ -0,0 +1,240 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+// feeRateBuckets are the lower bounds (in atomic units per kB) of the buckets
+// used to build the fee-rate histogram of the verified txs.
+var feeRateBuckets = []uint64{0, 100, 200, 500, 1000, 2000, 5000, 10000, 20000, 50000, 100000}
+
+// feeRate calculates the fee paid per kB of encoded tx
+func feeRate(fee, size uint64) uint64 {
+	if size == 0 {
+		return 0
+	}
+
+	return fee * 1000 / size
+}
+
+type (
+	// Info summarizes the state of the verified pool, and of the txs in
+	// their stem phase.
+	Info struct {
+		// Count is the number of verified txs
+		Count uint64 `json:"count"`
+		// Stem is the number of txs, among Count, in their stem phase
+		Stem uint64 `json:"stem"`
+		// Bytes is the overall encoded size of the verified txs
+		Bytes uint64 `json:"bytes"`
+		// MinFeeRate is the lowest fee rate (units per kB) in the pool
+		MinFeeRate uint64 `json:"minFeeRate"`
+		// OldestAge is the number of seconds since the oldest tx was received
+		OldestAge uint64 `json:"oldestAge"`
+		// Histogram of the verified txs by fee rate
+		Histogram []FeeBucket `json:"histogram"`
+	}
+
+	// FeeBucket counts the verified txs paying a fee rate of at least
+	// MinFeeRate, and lower than the MinFeeRate of the following bucket.
+	FeeBucket struct {
+		MinFeeRate uint64 `json:"minFeeRate"`
+		Count      uint64 `json:"count"`
+		Bytes      uint64 `json:"bytes"`
+	}
+
+	// Entry holds the meta data of a single verified tx. Stem is set while
+	// the tx is in its stem phase.
+	Entry struct {
+		TxID     string `json:"txid"`
+		Type     uint8  `json:"type"`
+		Stem     bool   `json:"stem"`
+		Fee      uint64 `json:"fee"`
+		Size     uint64 `json:"size"`
+		FeeRate  uint64 `json:"feeRate"`
+		Received int64  `json:"received"`
+		Verified int64  `json:"verified"`
+	}
+)
+
+// newInfo builds the summary of the verified and stem pools at the given point
+// in time.
+func newInfo(verified, stem Pool, now time.Time) Info {
+	info := Info{Histogram: make([]FeeBucket, len(feeRateBuckets)), Stem: uint64(stem.Len())}
+	for i, b := range feeRateBuckets {
+		info.Histogram[i].MinFeeRate = b
+	}
+
+	var oldest time.Time
+	add := func(k key, t TxDesc) error {
+		rate := t.feeRate()
+		if info.Count == 0 || rate < info.MinFeeRate {
+			info.MinFeeRate = rate
+		}
+
+		if oldest.IsZero() || t.received.Before(oldest) {
+			oldest = t.received
+		}
+
+		info.Count++
+		info.Bytes += t.size
+
+		bucket := &info.Histogram[bucketIndex(rate)]
+		bucket.Count++
+		bucket.Bytes += t.size
+		return nil
+	}
+
+	_ = verified.Range(add)
+	_ = stem.Range(add)
+
+	if !oldest.IsZero() {
+		info.OldestAge = uint64(now.Sub(oldest) / time.Second)
+	}
+
+	return info
+}
+
+func bucketIndex(rate uint64) int {
+	i := len(feeRateBuckets) - 1
+	for i > 0 && rate < feeRateBuckets[i] {
+		i--
+	}
+
+	return i
+}
+
+func newEntry(k key, t TxDesc) Entry {
+	return Entry{
+		TxID:     hex.EncodeToString(k[:]),
+		Type:     uint8(t.tx.Type()),
+		Stem:     t.stem,
+		Fee:      t.fee(),
+		Size:     t.size,
+		FeeRate:  t.feeRate(),
+		Received: t.received.Unix(),
+		Verified: t.verified.Unix(),
+	}
+}
+
+// Encode an Info struct and write it to w.
+func (i Info) Encode(w io.Writer) error {
+	for _, v := range []uint64{i.Count, i.Stem, i.Bytes, i.MinFeeRate, i.OldestAge} {
+		if err := encoding.WriteUint64(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(i.Histogram))); err != nil {
+		return err
+	}
+
+	for _, b := range i.Histogram {
+		for _, v := range []uint64{b.MinFeeRate, b.Count, b.Bytes} {
+			if err := encoding.WriteUint64(w, binary.LittleEndian, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Decode an Info struct from r.
+func (i *Info) Decode(r io.Reader) error {
+	for _, v := range []*uint64{&i.Count, &i.Stem, &i.Bytes, &i.MinFeeRate, &i.OldestAge} {
+		if err := encoding.ReadUint64(r, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	lBuckets, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	i.Histogram = make([]FeeBucket, lBuckets)
+	for j := range i.Histogram {
+		b := &i.Histogram[j]
+		for _, v := range []*uint64{&b.MinFeeRate, &b.Count, &b.Bytes} {
+			if err := encoding.ReadUint64(r, binary.LittleEndian, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Encode an Entry struct and write it to w.
+func (e Entry) Encode(w io.Writer) error {
+	if err := encoding.WriteString(w, e.TxID); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint8(w, e.Type); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteBool(w, e.Stem); err != nil {
+		return err
+	}
+
+	for _, v := range []uint64{e.Fee, e.Size, e.FeeRate, uint64(e.Received), uint64(e.Verified)} {
+		if err := encoding.WriteUint64(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode an Entry struct from r.
+func (e *Entry) Decode(r io.Reader) error {
+	if err := encoding.ReadString(r, &e.TxID); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadUint8(r, &e.Type); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadBool(r, &e.Stem); err != nil {
+		return err
+	}
+
+	var received, verified uint64
+	for _, v := range []*uint64{&e.Fee, &e.Size, &e.FeeRate, &received, &verified} {
+		if err := encoding.ReadUint64(r, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	e.Received = int64(received)
+	e.Verified = int64(verified)
+	return nil
+}
+
+// DecodeEntries decodes a list of entries, as returned by the
+// wire.GetMempoolEntries method.
+func DecodeEntries(r *bytes.Buffer) ([]Entry, error) {
+	lEntries, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, lEntries)
+	for i := range entries {
+		if err := entries[i].Decode(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"bytes"
 	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
@@ -16,6 +17,32 @@ type TxDesc struct {
 	verified time.Time
 	// the point in time, tx was accepted by this node
 	// accepted time.Time
+
+	// the size of the encoded tx in bytes
+	size uint64
+
+	// true if the tx is in its stem phase
+	stem bool
+}
+
+// newTxDesc wraps a tx received at the given point in time.
+func newTxDesc(tx transactions.Transaction, received time.Time) (TxDesc, error) {
+	buf := new(bytes.Buffer)
+	if err := tx.Encode(buf); err != nil {
+		return TxDesc{}, err
+	}
+
+	return TxDesc{tx: tx, received: received, size: uint64(buf.Len())}, nil
+}
+
+// fee returns the fee attached to the tx
+func (t TxDesc) fee() uint64 {
+	return t.tx.StandardTX().Fee
+}
+
+// feeRate returns the fee paid per kB of encoded tx
+func (t TxDesc) feeRate() uint64 {
+	return feeRate(t.fee(), t.size)
 }
 
 // Pool represents a transaction pool of the verified txs only.
@@ -2,6 +2,7 @@ package mempool
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"math"
@@ -28,6 +29,14 @@ const (
 	maxPendingLen    = 1000
 )
 
+var (
+	// errLimitReached stops the iteration of a pool once enough txs are collected
+	errLimitReached = errors.New("limit reached")
+	// errPendingFull is returned when a tx is dropped, as the verification of
+	// the pending txs lags behind
+	errPendingFull = errors.New("pending queue full, tx dropped")
+)
+
 // Mempool is a storage for the chain transactions that are valid according to the
 // current chain state and can be included in the next block.
 type Mempool struct {
@@ -39,12 +48,20 @@ type Mempool struct {
 	// verified txs to be included in next block
 	verified Pool
 
+	// verified txs in their stem phase. They are relayed to a single peer,
+	// and are neither advertised nor included in a block until they are
+	// broadcast.
+	stem Pool
+
 	// the collector to listen for new accepted blocks
 	accepted Collector
 
 	// used by tx verification procedure
 	latestBlockTimestamp int64
 
+	// suggests fee rates based on the accepted blocks and the verified pool
+	estimator feeEstimator
+
 	eventBus *wire.EventBus
 	db       database.DB
 
@@ -100,6 +117,7 @@ func NewMempool(eventBus *wire.EventBus, verifyTx func(tx transactions.Transacti
 	}
 
 	m.verified = m.newPool()
+	m.stem = m.newPool()
 
 	log.Infof("running with pool type %s", config.Get().Mempool.PoolType)
 
@@ -107,6 +125,10 @@ func NewMempool(eventBus *wire.EventBus, verifyTx func(tx transactions.Transacti
 	m.pending = make(chan TxDesc, maxPendingLen)
 	go wire.NewTopicListener(m.eventBus, m, string(topics.Tx)).Accept()
 
+	// topics.StemTx will be published by the Peer subsystem for the txs relayed
+	// to us in their stem phase, or by the wallet for our own txs
+	go wire.NewTopicListener(m.eventBus, &stemCollector{m}, string(topics.StemTx)).Accept()
+
 	// topics.AcceptedBlock will be published by Chain subsystem when new block is accepted into blockchain
 	m.accepted.blockChan = make(chan block.Block)
 	go wire.NewTopicListener(m.eventBus, &m.accepted, string(topics.AcceptedBlock)).Accept()
@@ -126,6 +148,16 @@ func (m *Mempool) Run() {
 			select {
 			case r := <-wire.GetMempoolTxsChan:
 				m.onGetMempoolTxs(r)
+			case r := <-wire.SendMempoolTxChan:
+				m.onSendMempoolTx(r)
+			case r := <-wire.GetMempoolTxsBySizeChan:
+				m.onGetMempoolTxsBySize(r)
+			case r := <-wire.EstimateFeeChan:
+				m.onEstimateFee(r)
+			case r := <-wire.GetMempoolInfoChan:
+				m.onGetMempoolInfo(r)
+			case r := <-wire.GetMempoolEntriesChan:
+				m.onGetMempoolEntries(r)
 			// Mempool input channels
 			case b := <-m.accepted.blockChan:
 				m.onAcceptedBlock(b)
@@ -142,15 +174,52 @@ func (m *Mempool) Run() {
 }
 
 // onPendingTx ensures all transaction rules are satisfied before adding the tx
-// into the verified pool
+// into the verified pool. If the tx is rejected, a topics.Reject event is
+// published so that the peer which relayed it can be notified.
 func (m *Mempool) onPendingTx(t TxDesc) {
 	// stats to log
 	log.Tracef("pending txs count %d", len(m.pending))
 
+	if _, err := m.processTx(t); err != nil {
+		if reject, ok := err.(*peermsg.Reject); ok {
+			m.publishReject(reject)
+		}
+	}
+}
+
+// onSendMempoolTx handles a tx submitted through the RPCBus. Unlike the pending
+// txs, the verification result is returned to the caller. The tx originates
+// from this node, so it starts in the stem phase.
+func (m *Mempool) onSendMempoolTx(r wire.Req) {
+	txs, err := transactions.FromReader(&r.Params, 1)
+	if err != nil {
+		r.ErrChan <- peermsg.NewReject(topics.Tx, peermsg.RejectMalformed, err.Error(), nil)
+		return
+	}
+
+	t, err := newTxDesc(txs[0], time.Now())
+	if err != nil {
+		r.ErrChan <- peermsg.NewReject(topics.Tx, peermsg.RejectMalformed, err.Error(), nil)
+		return
+	}
+
+	t.stem = true
+	txID, err := m.processTx(t)
+	if err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	r.RespChan <- *bytes.NewBuffer(txID)
+}
+
+// processTx runs all the checks on a tx, and adds it to the verified pool if
+// they pass. A rejected tx results in a *peermsg.Reject error.
+func (m *Mempool) processTx(t TxDesc) ([]byte, error) {
 	txID, err := t.tx.CalculateHash()
 	if err != nil {
 		log.Tracef("calculate tx hash failed: %s", err.Error())
-		return
+		return nil, peermsg.NewReject(topics.Tx, peermsg.RejectMalformed, err.Error(), nil)
 	}
 
 	log := logEntry("tx", toHex(txID[:]))
@@ -158,45 +227,97 @@ func (m *Mempool) onPendingTx(t TxDesc) {
 	if t.tx.Type() == transactions.CoinbaseType {
 		// coinbase tx should be built by block generator only
 		log.Warnf("coinbase tx not allowed")
-		return
+		return nil, peermsg.NewReject(topics.Tx, peermsg.RejectNotAllowed, "coinbase tx not allowed", txID)
 	}
 
 	// expect it is not already a verified tx
 	if m.verified.Contains(txID) {
 		log.Warnf("already exists")
-		return
+		return nil, peermsg.NewReject(topics.Tx, peermsg.RejectDuplicate, "already exists", txID)
+	}
+
+	// a stem tx received again is broadcast, unless it is still in its stem
+	// phase, in which case it made a loop
+	if m.stem.Contains(txID) {
+		if t.stem {
+			log.Warnf("already exists in stem phase")
+			return nil, peermsg.NewReject(topics.Tx, peermsg.RejectDuplicate, "already exists", txID)
+		}
+
+		return txID, m.fluff(txID)
 	}
 
 	// expect it is not already spent from mempool verified txs
 	if err := m.checkTXDoubleSpent(t.tx); err != nil {
 		log.Warnf("double-spending: %v", err)
-		return
+		return nil, peermsg.NewReject(topics.Tx, peermsg.RejectDoubleSpend, err.Error(), txID)
 	}
 
 	// execute tx verification procedure
 	if err := m.checkTx(t.tx); err != nil {
 		log.Errorf("verification: %v", err)
-		return
+		return nil, peermsg.NewReject(topics.Tx, rejectCode(err), err.Error(), txID)
 	}
 
 	// if consumer's verification passes, mark it as verified
 	t.verified = time.Now()
 
+	// the stem txs are handed to the relay, which sends them to a single peer
+	if t.stem {
+		if err := m.stem.Put(t); err != nil {
+			log.Errorf("store: %v", err)
+			return nil, err
+		}
+
+		m.relayStem(t.tx)
+		return txID, nil
+	}
+
 	// we've got a valid transaction pushed
 	if err := m.verified.Put(t); err != nil {
 		log.Errorf("store: %v", err)
-		return
+		return nil, err
 	}
 
 	// advertise the hash of the verified Tx to the P2P network
 	if err := m.advertiseTx(txID); err != nil {
 		log.Errorf("advertise: %v", err)
-		return
+		return nil, err
 	}
+
+	return txID, nil
+}
+
+// fluff ends the stem phase of a tx, moving it to the verified pool and
+// advertising it to the P2P network.
+func (m *Mempool) fluff(txID []byte) error {
+	var t TxDesc
+	stem := m.newPool()
+	err := m.stem.Range(func(k key, desc TxDesc) error {
+		if bytes.Equal(k[:], txID) {
+			t = desc
+			return nil
+		}
+
+		return stem.Put(desc)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	m.stem = stem
+	t.stem = false
+	if err := m.verified.Put(t); err != nil {
+		return err
+	}
+
+	return m.advertiseTx(txID)
 }
 
 func (m *Mempool) onAcceptedBlock(b block.Block) {
 	m.latestBlockTimestamp = b.Header.Timestamp
+	m.estimator.onBlock(b)
 	m.removeAccepted(b)
 }
 
@@ -212,7 +333,7 @@ func (m *Mempool) removeAccepted(b block.Block) {
 
 	log.Infof("processing an accepted block with %d txs", len(b.Txs))
 
-	if m.verified.Len() == 0 {
+	if m.verified.Len() == 0 && m.stem.Len() == 0 {
 		// No txs accepted then no cleanup needed
 		return
 	}
@@ -231,26 +352,33 @@ func (m *Mempool) removeAccepted(b block.Block) {
 			return
 		}
 
-		s := m.newPool()
-		// Check if mempool verified tx is part of merkle tree of this block
-		// if not, then keep it in the mempool for the next block
-		err = m.verified.Range(func(k key, t TxDesc) error {
-			if r, _ := tree.VerifyContent(t.tx); !r {
-				if err := s.Put(t); err != nil {
-					return err
-				}
-			}
-			return nil
-		})
+		m.verified = m.withoutAccepted(m.verified, tree)
+		m.stem = m.withoutAccepted(m.stem, tree)
+	}
+
+	log.Infof("processing completed")
+}
 
-		if err != nil {
-			log.Error(err.Error())
+// withoutAccepted returns a copy of the pool without the txs of the merkle
+// tree of an accepted block.
+func (m *Mempool) withoutAccepted(p Pool, tree *merkletree.Tree) Pool {
+	s := m.newPool()
+	// Check if mempool verified tx is part of merkle tree of this block
+	// if not, then keep it in the mempool for the next block
+	err := p.Range(func(k key, t TxDesc) error {
+		if r, _ := tree.VerifyContent(t.tx); !r {
+			if err := s.Put(t); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
 
-		m.verified = s
+	if err != nil {
+		log.Error(err.Error())
 	}
 
-	log.Infof("processing completed")
+	return s
 }
 
 func (m *Mempool) onIdle() {
@@ -309,13 +437,38 @@ func (m *Mempool) newPool() Pool {
 // Fast-processing and simple impl to avoid locking here.
 // NB This is always run in a different than main mempool routine
 func (m *Mempool) Collect(message *bytes.Buffer) error {
+	return m.collect(message, false)
+}
+
+// stemCollector collects the txs emitted in their stem phase
+type stemCollector struct {
+	m *Mempool
+}
+
+func (s *stemCollector) Collect(message *bytes.Buffer) error {
+	return s.m.collect(message, true)
+}
+
+func (m *Mempool) collect(message *bytes.Buffer, stem bool) error {
 
 	txs, err := transactions.FromReader(message, 1)
 	if err != nil {
 		return err
 	}
 
-	m.pending <- TxDesc{tx: txs[0], received: time.Now()}
+	t, err := newTxDesc(txs[0], time.Now())
+	if err != nil {
+		return err
+	}
+
+	t.stem = stem
+
+	// the collectors must not block the event bus
+	select {
+	case m.pending <- t:
+	default:
+		return errPendingFull
+	}
 
 	return nil
 }
@@ -324,45 +477,61 @@ func (m *Mempool) Collect(message *bytes.Buffer) error {
 // still unaccepted txs
 func (m Mempool) onGetMempoolTxs(r wire.Req) {
 
-	// Read inputs
+	// Read inputs, which are either a TxID or the maximum amount of txs to
+	// return
 	filterTxID := r.Params.Bytes()
+	var limit uint32
+	if len(filterTxID) == 4 {
+		limit = binary.LittleEndian.Uint32(filterTxID)
+		filterTxID = nil
+	}
 
 	outputTxs := make([]transactions.Transaction, 0)
 
-	// TODO: When filterTxID is empty, mempool returns the all verified
-	// txs. Once the limit of transactions space in a block is determined,
-	// mempool should prioritize transactions by fee
+	// When filterTxID is empty, mempool returns all the verified txs, up to
+	// the limit. Block generators should use wire.GetMempoolTxsBySize instead.
 	err := m.verified.Range(func(k key, t TxDesc) error {
-		if len(filterTxID) > 0 {
-			if bytes.Equal(filterTxID, k[:]) {
-				// tx found
-				outputTxs = append(outputTxs, t.tx)
-				return nil
-			}
-		} else if len(outputTxs) < 50 {
-			// Non-filter scan for max 50 transactions.
-			// TODO: this should be properly determined ASAP (maybe by adding size checks that determine
-			// the amount of kB a transaction takes up)
+		if limit > 0 && uint32(len(outputTxs)) >= limit {
+			return errLimitReached
+		}
+
+		if len(filterTxID) == 0 || bytes.Equal(filterTxID, k[:]) {
 			outputTxs = append(outputTxs, t.tx)
 		}
 
 		return nil
 	})
 
-	if err != nil {
+	if err != nil && err != errLimitReached {
 		r.ErrChan <- err
 		return
 	}
 
-	// marshal Txs
+	replyTxs(r, outputTxs)
+}
+
+// onGetMempoolTxsBySize retrieves the verified txs which fit in the given amount
+// of bytes, prioritized by fee rate.
+func (m Mempool) onGetMempoolTxsBySize(r wire.Req) {
+	var maxSize uint32
+	if err := encoding.ReadUint32(&r.Params, binary.LittleEndian, &maxSize); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	replyTxs(r, assemble(m.verified, uint64(maxSize)))
+}
+
+// replyTxs marshals a list of txs and sends it back to the requester
+func replyTxs(r wire.Req, txs []transactions.Transaction) {
 	w := new(bytes.Buffer)
-	lTxs := uint64(len(outputTxs))
+	lTxs := uint64(len(txs))
 	if err := encoding.WriteVarInt(w, lTxs); err != nil {
 		r.ErrChan <- err
 		return
 	}
 
-	for _, tx := range outputTxs {
+	for _, tx := range txs {
 		if err := tx.Encode(w); err != nil {
 			r.ErrChan <- err
 			return
@@ -372,12 +541,75 @@ func (m Mempool) onGetMempoolTxs(r wire.Req) {
 	r.RespChan <- *w
 }
 
+// onEstimateFee suggests the fee rate for a tx to be included within a target
+// amount of blocks
+func (m Mempool) onEstimateFee(r wire.Req) {
+	var target uint32
+	if err := encoding.ReadUint32(&r.Params, binary.LittleEndian, &target); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	w := new(bytes.Buffer)
+	if err := m.estimator.estimate(target, m.verified).Encode(w); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	r.RespChan <- *w
+}
+
+// onGetMempoolInfo returns a summary of the verified and stem pools, including
+// a histogram of the txs by fee rate
+func (m Mempool) onGetMempoolInfo(r wire.Req) {
+	w := new(bytes.Buffer)
+	if err := newInfo(m.verified, m.stem, time.Now()).Encode(w); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	r.RespChan <- *w
+}
+
+// onGetMempoolEntries returns the meta data of the verified txs, including the
+// ones in their stem phase. If a TxID is provided, only the matching entry is
+// returned.
+func (m Mempool) onGetMempoolEntries(r wire.Req) {
+	filterTxID := r.Params.Bytes()
+
+	entries := make([]Entry, 0)
+	add := func(k key, t TxDesc) error {
+		if len(filterTxID) == 0 || bytes.Equal(filterTxID, k[:]) {
+			entries = append(entries, newEntry(k, t))
+		}
+		return nil
+	}
+
+	_ = m.verified.Range(add)
+	_ = m.stem.Range(add)
+
+	w := new(bytes.Buffer)
+	if err := encoding.WriteVarInt(w, uint64(len(entries))); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	for _, e := range entries {
+		if err := e.Encode(w); err != nil {
+			r.ErrChan <- err
+			return
+		}
+	}
+
+	r.RespChan <- *w
+}
+
 // checkTXDoubleSpent differs from verifiers.checkTXDoubleSpent as it executes
 // all checks against mempool verified txs but not blockchain db.
 func (m *Mempool) checkTXDoubleSpent(tx transactions.Transaction) error {
 
 	for _, input := range tx.StandardTX().Inputs {
-		exists := m.verified.ContainsKeyImage(input.KeyImage)
+		exists := m.verified.ContainsKeyImage(input.KeyImage) || m.stem.ContainsKeyImage(input.KeyImage)
 		if exists {
 			return errors.New("tx already spent")
 		}
@@ -402,13 +634,43 @@ func (m *Mempool) advertiseTx(txID []byte) error {
 		panic(err)
 	}
 
-	withTopic, err := wire.AddTopic(buf, topics.Inv)
-	if err != nil {
-		return err
+	// the peers which do not know about the tx are told with the next trickle
+	m.eventBus.Publish(string(topics.Inv), buf)
+	return nil
+}
+
+// relayStem hands a tx in its stem phase to the P2P relay
+func (m *Mempool) relayStem(tx transactions.Transaction) {
+	buf := new(bytes.Buffer)
+	if err := tx.Encode(buf); err != nil {
+		log.Errorf("encoding stem tx: %v", err)
+		return
 	}
 
-	m.eventBus.Stream(string(topics.Gossip), withTopic)
-	return nil
+	m.eventBus.Publish(string(topics.Stem), buf)
+}
+
+// publishReject notifies the other subsystems about a rejected tx
+func (m *Mempool) publishReject(reject *peermsg.Reject) {
+	buf := new(bytes.Buffer)
+	if err := reject.Encode(buf); err != nil {
+		log.Errorf("encoding reject: %v", err)
+		return
+	}
+
+	m.eventBus.Publish(string(topics.Reject), buf)
+}
+
+// rejectCode maps a verification error onto a peermsg.RejectCode
+func rejectCode(err error) peermsg.RejectCode {
+	switch err {
+	case verifiers.ErrFeeTooLow:
+		return peermsg.RejectFeeTooLow
+	case verifiers.ErrAlreadySpent:
+		return peermsg.RejectDoubleSpend
+	}
+
+	return peermsg.RejectInvalid
 }
 
 func toHex(id []byte) string {
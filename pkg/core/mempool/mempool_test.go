@@ -3,6 +3,7 @@ package mempool
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"math"
 	"sync"
@@ -13,6 +14,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -59,25 +61,29 @@ func initCtx(t *testing.T) *ctx {
 		r.Mempool.PoolType = "hashmap"
 		config.Mock(&r)
 		// eventBus
-		var streamer *helper.SimpleStreamer
-		c.bus, streamer = helper.CreateGossipStreamer()
+		c.bus = wire.NewEventBus()
 		// creating the rpcbus
 		c.rpcBus = wire.NewRPCBus()
 
 		c.propagated = make([][]byte, 0)
 
-		go func(streamer *helper.SimpleStreamer, c *ctx) {
-			for {
-				tx, err := streamer.Read()
-				if err != nil {
+		// the verified txs are advertised to the peers through topics.Inv
+		invChan := make(chan *bytes.Buffer, 100)
+		c.bus.Subscribe(string(topics.Inv), invChan)
+		go func(invChan chan *bytes.Buffer, c *ctx) {
+			for m := range invChan {
+				inv := &peermsg.Inv{}
+				if err := inv.Decode(m); err != nil {
 					t.Fatal(err)
 				}
 
 				c.mu.Lock()
-				c.propagated = append(c.propagated, tx)
+				for _, item := range inv.InvList {
+					c.propagated = append(c.propagated, item.Hash)
+				}
 				c.mu.Unlock()
 			}
-		}(streamer, c)
+		}(invChan, c)
 
 		// initiate a mempool with custom verification function
 		c.m = NewMempool(c.bus, verifyFunc)
@@ -86,6 +92,7 @@ func initCtx(t *testing.T) *ctx {
 		// Reset shared context state
 		c.m.Quit()
 		c.m.verified = c.m.newPool()
+		c.m.stem = c.m.newPool()
 		c.verifiedTx = make([]transactions.Transaction, 0)
 		c.propagated = make([][]byte, 0)
 	}
@@ -197,6 +204,41 @@ func TestProcessPendingTxs(t *testing.T) {
 
 }
 
+// TestGetMempoolTxsLimit ensures that no more txs than requested are returned
+func TestGetMempoolTxsLimit(t *testing.T) {
+
+	initCtx(t)
+
+	for _, tx := range randomSliceOfTxs(t, 1) {
+		buf := new(bytes.Buffer)
+		if err := tx.Encode(buf); err != nil {
+			t.Fatal(err)
+		}
+
+		c.addTx(tx)
+		c.bus.Publish(string(topics.Tx), buf)
+	}
+
+	c.assert(t, true)
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint32(buf, binary.LittleEndian, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := c.rpcBus.Call(wire.GetMempoolTxs, wire.NewRequest(*buf, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lTxs, err := encoding.ReadVarInt(&r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint64(2), lTxs)
+}
+
 func TestProcessPendingTxsAsync(t *testing.T) {
 
 	initCtx(t)
@@ -389,6 +431,263 @@ func TestCoinbaseTxsNotAllowed(t *testing.T) {
 	c.assert(t, true)
 }
 
+// TestSendMempoolTx ensures that a tx submitted through the RPCBus is verified
+// synchronously, and that a rejection carries the proper reject code.
+func TestSendMempoolTx(t *testing.T) {
+
+	initCtx(t)
+
+	tx := helper.RandomStandardTx(t, false)
+	buf := new(bytes.Buffer)
+	if err := tx.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	txBytes := buf.Bytes()
+
+	// First submission should be accepted, in the stem phase
+	r, err := c.rpcBus.Call(wire.SendMempoolTx, wire.NewRequest(*bytes.NewBuffer(txBytes), 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txID, _ := tx.CalculateHash()
+	assert.Equal(t, txID, r.Bytes())
+
+	// Second submission should be rejected as a duplicate
+	_, err = c.rpcBus.Call(wire.SendMempoolTx, wire.NewRequest(*bytes.NewBuffer(txBytes), 2))
+	reject, ok := err.(*peermsg.Reject)
+	if !ok {
+		t.Fatalf("expected a reject, got %v", err)
+	}
+
+	assert.Equal(t, peermsg.RejectDuplicate, reject.Code)
+	assert.Equal(t, txID, reject.Hash)
+
+	// A coinbase tx should never be accepted
+	buf = new(bytes.Buffer)
+	if err := helper.RandomCoinBaseTx(t, false).Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.rpcBus.Call(wire.SendMempoolTx, wire.NewRequest(*buf, 2))
+	reject, ok = err.(*peermsg.Reject)
+	if !ok {
+		t.Fatalf("expected a reject, got %v", err)
+	}
+
+	assert.Equal(t, peermsg.RejectNotAllowed, reject.Code)
+
+	// The tx is only advertised once broadcast
+	c.assert(t, true)
+	c.addTx(tx)
+	c.bus.Publish(string(topics.Tx), bytes.NewBuffer(txBytes))
+	c.assert(t, true)
+}
+
+// TestStemTx ensures that the txs in their stem phase are handed to the relay,
+// and kept out of the verified pool until they are broadcast.
+func TestStemTx(t *testing.T) {
+
+	initCtx(t)
+
+	stemChan := make(chan *bytes.Buffer, 1)
+	id := c.bus.Subscribe(string(topics.Stem), stemChan)
+	defer c.bus.Unsubscribe(string(topics.Stem), id)
+
+	tx := helper.RandomStandardTx(t, false)
+	buf := new(bytes.Buffer)
+	if err := tx.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	c.bus.Publish(string(topics.StemTx), bytes.NewBuffer(buf.Bytes()))
+
+	select {
+	case stem := <-stemChan:
+		assert.Equal(t, buf.Bytes(), stem.Bytes())
+	case <-time.After(time.Second):
+		t.Fatal("stem tx was not relayed")
+	}
+
+	c.assert(t, true)
+
+	// Receiving the tx again in its stem phase should not relay it twice
+	c.bus.Publish(string(topics.StemTx), bytes.NewBuffer(buf.Bytes()))
+	c.wait()
+	assert.Empty(t, stemChan)
+
+	// Broadcasting the tx moves it to the verified pool
+	c.addTx(tx)
+	c.bus.Publish(string(topics.Tx), bytes.NewBuffer(buf.Bytes()))
+	c.assert(t, true)
+}
+
+// TestCollectPendingFull ensures that the txs are dropped rather than blocking
+// the collector once the pending queue is full.
+func TestCollectPendingFull(t *testing.T) {
+	m := &Mempool{pending: make(chan TxDesc, 1)}
+	for i := 0; i < 2; i++ {
+		buf := new(bytes.Buffer)
+		if err := helper.RandomStandardTx(t, false).Encode(buf); err != nil {
+			t.Fatal(err)
+		}
+
+		err := m.Collect(buf)
+		if i == 0 {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, errPendingFull, err)
+		}
+	}
+
+	assert.Equal(t, 1, len(m.pending))
+}
+
+// TestGetMempoolInfo ensures that the summary and the entries of the mempool
+// reflect the submitted txs, which start in their stem phase.
+func TestGetMempoolInfo(t *testing.T) {
+
+	initCtx(t)
+
+	tx := helper.RandomStandardTx(t, false)
+	buf := new(bytes.Buffer)
+	if err := tx.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	size := uint64(buf.Len())
+
+	if _, err := c.rpcBus.Call(wire.SendMempoolTx, wire.NewRequest(*buf, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := c.rpcBus.Call(wire.GetMempoolInfo, wire.NewRequest(bytes.Buffer{}, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := Info{}
+	if err := info.Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	rate := feeRate(tx.Fee, size)
+	assert.Equal(t, uint64(1), info.Count)
+	assert.Equal(t, uint64(1), info.Stem)
+	assert.Equal(t, size, info.Bytes)
+	assert.Equal(t, rate, info.MinFeeRate)
+	assert.Equal(t, len(feeRateBuckets), len(info.Histogram))
+	assert.Equal(t, uint64(1), info.Histogram[bucketIndex(rate)].Count)
+
+	txID, _ := tx.CalculateHash()
+	r, err = c.rpcBus.Call(wire.GetMempoolEntries, wire.NewRequest(*bytes.NewBuffer(txID), 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := DecodeEntries(&r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, hex.EncodeToString(txID), entries[0].TxID)
+	assert.True(t, entries[0].Stem)
+	assert.Equal(t, tx.Fee, entries[0].Fee)
+	assert.Equal(t, size, entries[0].Size)
+	assert.True(t, entries[0].Verified >= entries[0].Received)
+
+	// the stem txs are not handed out with the verified ones
+	c.assert(t, true)
+}
+
+// TestAssemble ensures that the txs of a candidate block are prioritized by fee
+// rate, fit in the given size and respect the per-type quotas.
+func TestAssemble(t *testing.T) {
+
+	quota := typeQuotas[transactions.StakeType]
+	typeQuotas[transactions.StakeType] = 1
+	defer func() { typeQuotas[transactions.StakeType] = quota }()
+
+	p := &HashMap{}
+	var descs []TxDesc
+	for i, fee := range []uint64{100, 300, 200} {
+		tx := helper.RandomStandardTx(t, false)
+		tx.Fee = fee
+
+		desc, err := newTxDesc(tx, time.Now().Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		descs = append(descs, desc)
+		assert.NoError(t, p.Put(desc))
+	}
+
+	for i := 0; i < 2; i++ {
+		stake, err := helper.RandomStakeTx(t, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// stakes pay less than any standard tx
+		stake.Fee = 0
+
+		desc, err := newTxDesc(stake, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, p.Put(desc))
+	}
+
+	// everything fits, except the second stake
+	txs := assemble(p, math.MaxUint32)
+	assert.Equal(t, 4, len(txs))
+	assert.True(t, txs[0].Equals(descs[1].tx))
+	assert.True(t, txs[1].Equals(descs[2].tx))
+	assert.True(t, txs[2].Equals(descs[0].tx))
+	assert.Equal(t, transactions.StakeType, txs[3].Type())
+
+	// only the best paying tx fits
+	txs = assemble(p, descs[1].size)
+	assert.Equal(t, 1, len(txs))
+	assert.True(t, txs[0].Equals(descs[1].tx))
+}
+
+// TestEstimateFee ensures that the fee estimate follows both the fee rates paid
+// in full blocks, and the fee rates of the txs waiting in the mempool.
+func TestEstimateFee(t *testing.T) {
+
+	e := feeEstimator{}
+	p := &HashMap{}
+
+	// nothing seen yet, the minimum fee applies
+	estimate := e.estimate(1, p)
+	assert.Equal(t, uint64(0), estimate.FeeRate)
+	assert.Equal(t, uint64(config.MinFee), estimate.Fee)
+
+	// blocks which are not full do not matter
+	e.history = []blockStats{{minFeeRate: 5000}, {minFeeRate: 300, full: true}, {minFeeRate: 100, full: true}, {minFeeRate: 200, full: true}}
+	assert.Equal(t, uint64(200), e.estimate(1, p).FeeRate)
+	assert.Equal(t, uint64(100), e.estimate(MaxEstimateTarget, p).FeeRate)
+
+	// the fee follows the size of the tx, above the minimum fee
+	assert.Equal(t, uint64(1000), e.estimate(1, p).FeeFor(5000))
+	assert.Equal(t, uint64(config.MinFee), e.estimate(1, p).FeeFor(10))
+
+	// a mempool filling up the next block requires outbidding it
+	tx := helper.RandomStandardTx(t, false)
+	tx.Fee = 1000
+	assert.NoError(t, p.Put(TxDesc{tx: tx, size: blockCapacity() + 1, received: time.Now()}))
+
+	rate := feeRate(tx.Fee, blockCapacity()+1) + 1
+	if rate < 200 {
+		rate = 200
+	}
+	assert.Equal(t, rate, e.estimate(1, p).FeeRate)
+	assert.Equal(t, uint64(100), e.estimate(2, p).FeeRate)
+}
+
 // Only difference with helper.RandomSliceOfTxs is lack of appending a coinbase tx
 func randomSliceOfTxs(t *testing.T, txsBatchCount uint16) []transactions.Transaction {
 	var txs []transactions.Transaction
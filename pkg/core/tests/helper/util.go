@@ -128,5 +128,15 @@ func CreateGossipStreamer() (*wire.EventBus, *SimpleStreamer) {
 func StartPeerReader(conn net.Conn, bus *wire.EventBus, rpcBus *wire.RPCBus, counter *chainsync.Counter, responseChan chan<- *bytes.Buffer) (*peer.Reader, error) {
 	dupeMap := dupemap.NewDupeMap(5)
 	exitChan := make(chan struct{}, 1)
-	return peer.NewReader(conn, protocol.TestNet, dupeMap, bus, rpcBus, counter, responseChan, exitChan)
+	shared := &peer.Shared{
+		Counter:     counter,
+		Rejector:    processing.NewRejector(bus),
+		Requests:    processing.NewRequestTracker(),
+		InvRelay:    processing.NewInvRelay(bus),
+		Dandelion:   processing.NewDandelion(bus),
+		Broadcaster: processing.NewFlood(bus),
+		Limits:      processing.NewLimits(0, 0, nil),
+	}
+
+	return peer.NewReader(conn, protocol.TestNet, dupeMap, bus, rpcBus, shared, responseChan, exitChan)
 }
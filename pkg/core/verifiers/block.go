@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus/agreement"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus/committee"
@@ -12,8 +13,50 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/crypto/bls"
 )
 
+var (
+	// ErrBlockExists is returned when a block is already stored in the blockchain
+	ErrBlockExists = errors.New("block already exists")
+
+	// ErrBlockTooLarge is returned when an encoded block exceeds config.MaxBlockSize
+	ErrBlockTooLarge = errors.New("block exceeds the maximum block size")
+
+	// ErrPrevBlockMismatch is returned when a block does not follow the
+	// previous block
+	ErrPrevBlockMismatch = errors.New("Previous block hash does not equal the previous hash in the current block")
+
+	// ErrHeightMismatch is returned when a block is not at the height
+	// following the previous block
+	ErrHeightMismatch = errors.New("current block height is not one plus the previous block height")
+)
+
+// RuleError is returned when a block breaks one of the consensus rules, as
+// opposed to the errors caused by the node itself, or by a block which does
+// not follow the previous block.
+type RuleError struct {
+	Err error
+}
+
+func (e RuleError) Error() string {
+	return e.Err.Error()
+}
+
+// IsRuleError returns true if err is a RuleError.
+func IsRuleError(err error) bool {
+	_, ok := err.(RuleError)
+	return ok
+}
+
+// ruleError wraps a non-nil error into a RuleError
+func ruleError(err error) error {
+	if err == nil || IsRuleError(err) {
+		return err
+	}
+
+	return RuleError{err}
+}
+
 // CheckBlock will verify whether a block is valid according to the rules of the consensus
-// returns nil if a block is valid
+// returns nil if a block is valid. The blocks breaking the rules yield a RuleError.
 func CheckBlock(db database.DB, prevBlock block.Block, blk block.Block) error {
 	// 1. Check that we have not seen this block before
 	err := db.View(func(t database.Transaction) error {
@@ -23,7 +66,7 @@ func CheckBlock(db database.DB, prevBlock block.Block, blk block.Block) error {
 
 	if err != database.ErrBlockNotFound {
 		if err == nil {
-			err = errors.New("block already exists")
+			err = ErrBlockExists
 		}
 		return err
 	}
@@ -32,24 +75,56 @@ func CheckBlock(db database.DB, prevBlock block.Block, blk block.Block) error {
 		return err
 	}
 
+	if err := CheckBlockSize(blk); err != nil {
+		return ruleError(err)
+	}
+
 	if err := CheckMultiCoinbases(blk.Txs); err != nil {
-		return err
+		return ruleError(err)
 	}
 
+	reward := BlockReward(blk.Header.Height, blk.Txs)
 	for i, merklePayload := range blk.Txs {
 		tx, ok := merklePayload.(transactions.Transaction)
 		if !ok {
-			return errors.New("tx does not implement the transaction interface")
+			return ruleError(errors.New("tx does not implement the transaction interface"))
+		}
+
+		if coinbase, ok := tx.(*transactions.Coinbase); ok {
+			if err := VerifyCoinbase(uint64(i), coinbase, reward); err != nil {
+				return ruleError(err)
+			}
+			continue
 		}
+
 		if err := CheckTx(db, uint64(i), uint64(blk.Header.Timestamp), tx); err != nil {
-			return err
+			return ruleError(err)
 		}
 	}
 	return nil
 }
 
-// CheckBlockCertificate ensures that the block certificate is valid.
+// CheckBlockSize ensures that the encoded block does not exceed config.MaxBlockSize
+func CheckBlockSize(blk block.Block) error {
+	buf := new(bytes.Buffer)
+	if err := blk.Encode(buf); err != nil {
+		return err
+	}
+
+	if buf.Len() > config.MaxBlockSize {
+		return ErrBlockTooLarge
+	}
+
+	return nil
+}
+
+// CheckBlockCertificate ensures that the block certificate is valid. An
+// invalid certificate is a RuleError.
 func CheckBlockCertificate(committee committee.Foldable, blk block.Block) error {
+	return ruleError(checkBlockCertificate(committee, blk))
+}
+
+func checkBlockCertificate(committee committee.Foldable, blk block.Block) error {
 	if blk.Header.Height < 2 {
 		return nil
 	}
@@ -92,34 +167,47 @@ func checkBlockCertificateForStep(batchedSig *bls.Signature, bitSet uint64, roun
 // These are stateless and stateful checks
 // returns nil, if all checks pass
 func CheckBlockHeader(prevBlock block.Block, blk block.Block) error {
-	// Version
-	if blk.Header.Version > 0 {
-		return errors.New("unsupported block version")
+	if err := CheckHeader(prevBlock.Header, blk.Header); err != nil {
+		return err
 	}
 
-	// blk.Headerhash = prevHeaderHash
-	if !bytes.Equal(blk.Header.PrevBlockHash, prevBlock.Header.Hash) {
-		return errors.New("Previous block hash does not equal the previous hash in the current block")
+	// Merkle tree check -- Check is here as the root is not calculated on decode
+	tR := blk.Header.TxRoot
+	if err := blk.SetRoot(); err != nil {
+		return ruleError(errors.New("could not calculate the merkle tree root for this header"))
 	}
 
-	// blk.Headerheight = prevHeaderHeight +1
-	if blk.Header.Height != prevBlock.Header.Height+1 {
-		return errors.New("current block height is not one plus the previous block height")
+	if !bytes.Equal(tR, blk.Header.TxRoot) {
+		return ruleError(errors.New("merkle root mismatch"))
 	}
 
-	// blk.Timestamp > prevTimestamp
-	if blk.Header.Timestamp <= prevBlock.Header.Timestamp {
-		return errors.New("current timestamp is less than the previous timestamp")
+	return nil
+}
+
+// CheckHeader checks whether a header is supported, and follows the header of
+// the previous block. It does not need the block txs, so that the headers can
+// be checked before the blocks are downloaded.
+// A header which does not follow the previous one yields ErrPrevBlockMismatch
+// or ErrHeightMismatch, and the other failures are RuleErrors.
+func CheckHeader(prevHeader *block.Header, header *block.Header) error {
+	// Version
+	if header.Version > 0 {
+		return ruleError(errors.New("unsupported block version"))
 	}
 
-	// Merkle tree check -- Check is here as the root is not calculated on decode
-	tR := blk.Header.TxRoot
-	if err := blk.SetRoot(); err != nil {
-		return errors.New("could not calculate the merkle tree root for this header")
+	// blk.Headerhash = prevHeaderHash
+	if !bytes.Equal(header.PrevBlockHash, prevHeader.Hash) {
+		return ErrPrevBlockMismatch
 	}
 
-	if !bytes.Equal(tR, blk.Header.TxRoot) {
-		return errors.New("merkle root mismatch")
+	// blk.Headerheight = prevHeaderHeight +1
+	if header.Height != prevHeader.Height+1 {
+		return ErrHeightMismatch
+	}
+
+	// blk.Timestamp > prevTimestamp
+	if header.Timestamp <= prevHeader.Timestamp {
+		return ruleError(errors.New("current timestamp is less than the previous timestamp"))
 	}
 
 	return nil
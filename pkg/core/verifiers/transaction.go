@@ -13,6 +13,15 @@ import (
 	"github.com/pkg/errors"
 )
 
+var (
+	// ErrFeeTooLow is returned when a tx pays less than config.MinFee
+	ErrFeeTooLow = errors.New("fee too low")
+
+	// ErrAlreadySpent is returned when a tx input key image is already
+	// stored in the blockchain
+	ErrAlreadySpent = errors.New("already spent")
+)
+
 // CheckTx will verify whether a transaction is valid by checking:
 // - It has not been double spent
 // - It is not malformed
@@ -47,7 +56,7 @@ func CheckStandardTx(db database.DB, tx transactions.Standard) error {
 	}
 
 	if tx.Fee < uint64(config.MinFee) {
-		return errors.New("fee too low")
+		return ErrFeeTooLow
 	}
 
 	// Inputs - must contain at least one
@@ -109,7 +118,8 @@ func CheckSpecialFields(txIndex uint64, blockTime uint64, tx transactions.Transa
 	case *transactions.Bid:
 		return VerifyBid(txIndex, blockTime, x)
 	case *transactions.Coinbase:
-		return VerifyCoinbase(txIndex, x)
+		// the coinbase reward depends on the block height and fees
+		return errors.New("coinbase transaction can only be verified along with its block")
 	case *transactions.Stake:
 		return VerifyStake(txIndex, blockTime, x)
 	case *transactions.Standard:
@@ -123,7 +133,10 @@ func VerifyStandard(tx *transactions.Standard) error {
 	return nil
 }
 
-func VerifyCoinbase(txIndex uint64, tx *transactions.Coinbase) error {
+// VerifyCoinbase ensures that the coinbase tx is the first of the block, and
+// that it rewards the block generator with the expected amount, which is the
+// emission of the block plus the fees of the block txs.
+func VerifyCoinbase(txIndex uint64, tx *transactions.Coinbase, reward uint64) error {
 	if txIndex != 0 {
 		return errors.New("coinbase transaction is not in the first position")
 	}
@@ -132,16 +145,28 @@ func VerifyCoinbase(txIndex uint64, tx *transactions.Coinbase) error {
 		return fmt.Errorf("coinbase transaction must include 1 reward output")
 	}
 
-	// Ensure the reward is the fixed one
 	rewardScalar := ristretto.Scalar{}
 	rewardScalar.UnmarshalBinary(tx.Rewards[0].EncryptedAmount)
-	if rewardScalar.BigInt().Uint64() != config.GeneratorReward {
-		return fmt.Errorf("coinbase transaction must include a fixed reward of %d", config.GeneratorReward)
+	if rewardScalar.BigInt().Uint64() != reward {
+		return fmt.Errorf("coinbase transaction must include a reward of %d", reward)
 	}
 
 	return nil
 }
 
+// BlockReward calculates the coinbase reward of a block at the given height,
+// containing the given txs.
+func BlockReward(height uint64, txs []transactions.Transaction) uint64 {
+	reward := config.Emission().Reward(height)
+	for _, tx := range txs {
+		if tx.Type() != transactions.CoinbaseType {
+			reward += tx.StandardTX().Fee
+		}
+	}
+
+	return reward
+}
+
 func VerifyBid(index uint64, blockTime uint64, tx *transactions.Bid) error {
 	if err := checkLockTimeValid(tx.Lock, blockTime); err != nil {
 		return err
@@ -213,7 +238,7 @@ func checkTXDoubleSpent(db database.DB, inputs transactions.Inputs) error {
 		for _, input := range inputs {
 			exists, txID, _ := t.FetchKeyImageExists(input.KeyImage)
 			if exists || txID != nil {
-				return errors.New("already spent")
+				return ErrAlreadySpent
 			}
 		}
 
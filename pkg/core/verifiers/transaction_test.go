@@ -0,0 +1,44 @@
+package verifiers_test
+
+import (
+	"math/big"
+	"testing"
+
+	ristretto "github.com/bwesterb/go-ristretto"
+	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
+	"github.com/stretchr/testify/assert"
+)
+
+// The block reward should be the emission at the block height, plus the fees
+// of the block txs.
+func TestBlockReward(t *testing.T) {
+	// the previous config is restored for the following tests
+	prev := cfg.Get()
+	defer cfg.Mock(&prev)
+
+	r := cfg.Registry{}
+	r.General.Network = "testnet"
+	cfg.Mock(&r)
+
+	// a coinbase and 4 txs paying a fee of 20 each
+	txs := helper.RandomSliceOfTxs(t, 1)
+	emission := cfg.Emission()
+	assert.Equal(t, emission.Reward(0)+80, verifiers.BlockReward(0, txs))
+
+	// the fees are kept once the emission is over
+	assert.Equal(t, uint64(80), verifiers.BlockReward(emission.HalvingInterval*64, txs))
+}
+
+// The coinbase should only be valid with the exact reward.
+func TestVerifyCoinbase(t *testing.T) {
+	tx := helper.RandomCoinBaseTx(t, false)
+	var reward ristretto.Scalar
+	reward.SetBigInt(new(big.Int).SetUint64(cfg.GeneratorReward + 80))
+	tx.Rewards[0].EncryptedAmount = reward.Bytes()
+
+	assert.NoError(t, verifiers.VerifyCoinbase(0, tx, cfg.GeneratorReward+80))
+	assert.Error(t, verifiers.VerifyCoinbase(0, tx, cfg.GeneratorReward))
+	assert.Error(t, verifiers.VerifyCoinbase(1, tx, cfg.GeneratorReward+80))
+}
@@ -0,0 +1,448 @@
+package addrmgr
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	mrand "math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Every network group is assigned to a single bucket, so that a group can
+	// never take up more than bucketSize entries of the book.
+	numBuckets = 256
+	bucketSize = 64
+
+	// an address is considered bad after this amount of failed attempts
+	maxFailures = 3
+	// addresses not seen for this long are dropped first
+	staleAge = 30 * 24 * time.Hour
+)
+
+// saveInterval is the time between two dumps of the book on disk
+var saveInterval = 10 * time.Minute
+
+// KnownAddress is an entry of the address book
+type KnownAddress struct {
+	Addr        string `json:"addr"`
+	LastSeen    int64  `json:"lastSeen"`
+	LastSuccess int64  `json:"lastSuccess"`
+	LastAttempt int64  `json:"lastAttempt"`
+	Attempts    int    `json:"attempts"`
+	// ID is the hex encoded identity key of the node, learned once connected
+	ID string `json:"id,omitempty"`
+}
+
+func (ka *KnownAddress) isBad(now time.Time) bool {
+	if ka.Attempts >= maxFailures && ka.LastSuccess < ka.LastAttempt {
+		return true
+	}
+
+	return now.Sub(time.Unix(ka.LastSeen, 0)) > staleAge
+}
+
+// serialized form of the book
+type book struct {
+	Key       string          `json:"key"`
+	Addresses []*KnownAddress `json:"addresses"`
+}
+
+// AddrManager is a persistent book of the addresses of the known nodes. The
+// addresses are bucketed by network group (/16 for IPv4, /32 for IPv6), using
+// a secret key, so that a single operator can not fill the book with addresses
+// it controls.
+type AddrManager struct {
+	lock    sync.Mutex
+	key     [32]byte
+	buckets [numBuckets]map[string]*KnownAddress
+	file    string
+
+	quitChan chan struct{}
+}
+
+// New returns an AddrManager, loading the addresses stored in file, if any.
+func New(file string) *AddrManager {
+	a := &AddrManager{
+		file:     file,
+		quitChan: make(chan struct{}),
+	}
+
+	for i := range a.buckets {
+		a.buckets[i] = make(map[string]*KnownAddress)
+	}
+
+	if err := a.load(); err != nil {
+		log.WithFields(log.Fields{
+			"process": "addrmgr",
+			"file":    file,
+			"error":   err,
+		}).Warnln("could not load address book, starting with an empty one")
+		if _, err := rand.Read(a.key[:]); err != nil {
+			panic(err)
+		}
+	}
+
+	return a
+}
+
+// Start listening for the addresses received from the peers, serving the
+// wire.GetAddresses requests, and periodically saving the book.
+func (a *AddrManager) Start(subscriber wire.EventSubscriber) {
+	subscriber.SubscribeCallback(string(topics.Addr), a.onAddr)
+
+	go func() {
+		ticker := time.NewTicker(saveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case r := <-wire.GetAddressesChan:
+				a.onGetAddresses(r)
+			case <-ticker.C:
+				a.save()
+			case <-a.quitChan:
+				return
+			}
+		}
+	}()
+}
+
+// Close saves the book on disk
+func (a *AddrManager) Close() {
+	close(a.quitChan)
+	a.save()
+}
+
+// Add an address to the book. Addresses which can not be dialed are ignored.
+// If the bucket of the address is full, the worst entry is evicted.
+func (a *AddrManager) Add(addr string, lastSeen int64) {
+	netAddr, err := peermsg.NewNetAddress(addr, lastSeen)
+	if err != nil || !routable(netAddr) {
+		return
+	}
+	addr = netAddr.String()
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	bucket := a.buckets[a.bucketOf(netAddr.IP)]
+	if ka, ok := bucket[addr]; ok {
+		if lastSeen > ka.LastSeen {
+			ka.LastSeen = lastSeen
+		}
+		return
+	}
+
+	if len(bucket) >= bucketSize {
+		evict(bucket)
+	}
+
+	bucket[addr] = &KnownAddress{Addr: addr, LastSeen: lastSeen}
+}
+
+// Attempt marks a dial attempt to the given address
+func (a *AddrManager) Attempt(addr string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if ka := a.find(addr); ka != nil {
+		ka.LastAttempt = time.Now().Unix()
+		ka.Attempts++
+	}
+}
+
+// Good marks a successful connection to the given address, adding it to the
+// book if it is not known yet. id is the identity key of the node, if known.
+func (a *AddrManager) Good(addr string, id []byte) {
+	now := time.Now().Unix()
+	a.Add(addr, now)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if ka := a.find(addr); ka != nil {
+		ka.LastSuccess = now
+		ka.LastSeen = now
+		ka.Attempts = 0
+		if len(id) > 0 {
+			ka.ID = hex.EncodeToString(id)
+		}
+	}
+}
+
+// Select returns up to n addresses which are not in exclude. The addresses are
+// picked round-robin from randomly ordered buckets, to spread the connections
+// over as many network groups as possible. Bad addresses are skipped.
+func (a *AddrManager) Select(n int, exclude map[string]bool) []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	selected := make([]string, 0, n)
+	for _, ka := range a.selectAddresses(n, exclude) {
+		selected = append(selected, ka.Addr)
+	}
+
+	return selected
+}
+
+// SelectNodes returns up to n addresses which are not in exclude, among the
+// ones of the nodes whose identity key is known and accepted. Bad addresses
+// are skipped.
+func (a *AddrManager) SelectNodes(n int, exclude map[string]bool, accept func(id []byte) bool) []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := time.Now()
+	selected := make([]string, 0, n)
+	for _, i := range mrand.Perm(numBuckets) {
+		for addr, ka := range a.buckets[i] {
+			if len(selected) >= n {
+				return selected
+			}
+
+			if ka.ID == "" || exclude[addr] || ka.isBad(now) {
+				continue
+			}
+
+			id, err := hex.DecodeString(ka.ID)
+			if err == nil && accept(id) {
+				selected = append(selected, addr)
+			}
+		}
+	}
+
+	return selected
+}
+
+// selectAddresses implements Select. Must be called with the lock held.
+func (a *AddrManager) selectAddresses(n int, exclude map[string]bool) []KnownAddress {
+	now := time.Now()
+	groups := make([][]KnownAddress, 0)
+	for _, i := range mrand.Perm(numBuckets) {
+		group := make([]KnownAddress, 0)
+		for addr, ka := range a.buckets[i] {
+			if !exclude[addr] && !ka.isBad(now) {
+				group = append(group, *ka)
+			}
+		}
+
+		if len(group) > 0 {
+			mrand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+			groups = append(groups, group)
+		}
+	}
+
+	selected := make([]KnownAddress, 0, n)
+	for round := 0; len(selected) < n; round++ {
+		picked := false
+		for _, group := range groups {
+			if round < len(group) && len(selected) < n {
+				selected = append(selected, group[round])
+				picked = true
+			}
+		}
+
+		if !picked {
+			break
+		}
+	}
+
+	return selected
+}
+
+// Len returns the amount of addresses in the book
+func (a *AddrManager) Len() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	count := 0
+	for _, bucket := range a.buckets {
+		count += len(bucket)
+	}
+
+	return count
+}
+
+// onAddr adds the addresses of an Addr message received from a peer
+func (a *AddrManager) onAddr(m *bytes.Buffer) error {
+	msg := &peermsg.Addr{}
+	if err := msg.Decode(m); err != nil {
+		return err
+	}
+
+	// do not let peers advertise addresses from the future
+	now := time.Now().Unix()
+	for _, addr := range msg.Addresses {
+		lastSeen := addr.LastSeen
+		if lastSeen > now {
+			lastSeen = now
+		}
+
+		a.Add(addr.String(), lastSeen)
+	}
+
+	return nil
+}
+
+// onGetAddresses returns an Addr message with a selection of good addresses.
+// Param 1 is the uint16 maximum amount of addresses.
+func (a *AddrManager) onGetAddresses(r wire.Req) {
+	var max uint16
+	if err := encoding.ReadUint16(&r.Params, binary.LittleEndian, &max); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	if max > peermsg.MaxAddrs {
+		max = peermsg.MaxAddrs
+	}
+
+	a.lock.Lock()
+	selected := a.selectAddresses(int(max), nil)
+	a.lock.Unlock()
+
+	msg := &peermsg.Addr{}
+	for _, ka := range selected {
+		netAddr, err := peermsg.NewNetAddress(ka.Addr, ka.LastSeen)
+		if err != nil {
+			continue
+		}
+
+		msg.Addresses = append(msg.Addresses, *netAddr)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := msg.Encode(buf); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	r.RespChan <- *buf
+}
+
+// find an address in the book. Must be called with the lock held.
+func (a *AddrManager) find(addr string) *KnownAddress {
+	netAddr, err := peermsg.NewNetAddress(addr, 0)
+	if err != nil {
+		return nil
+	}
+
+	return a.buckets[a.bucketOf(netAddr.IP)][netAddr.String()]
+}
+
+// bucketOf returns the bucket index of the network group of ip
+func (a *AddrManager) bucketOf(ip net.IP) int {
+	h := sha256.New()
+	_, _ = h.Write(a.key[:])
+	_, _ = h.Write(group(ip))
+	sum := h.Sum(nil)
+	return int(binary.LittleEndian.Uint64(sum[:8]) % numBuckets)
+}
+
+// group returns the network group of ip: the /16 for IPv4, the /32 for IPv6
+func group(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4[:2]
+	}
+
+	return ip.To16()[:4]
+}
+
+func routable(addr *peermsg.NetAddress) bool {
+	return addr.Port != 0 && !addr.IP.IsUnspecified() && !addr.IP.IsMulticast()
+}
+
+// evict removes the worst entry of a full bucket: a bad one if any, or else
+// the one seen the longest time ago
+func evict(bucket map[string]*KnownAddress) {
+	now := time.Now()
+	var worst *KnownAddress
+	for _, ka := range bucket {
+		if ka.isBad(now) {
+			worst = ka
+			break
+		}
+
+		if worst == nil || ka.LastSeen < worst.LastSeen {
+			worst = ka
+		}
+	}
+
+	delete(bucket, worst.Addr)
+}
+
+func (a *AddrManager) load() error {
+	data, err := ioutil.ReadFile(a.file)
+	if err != nil {
+		return err
+	}
+
+	b := &book{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return err
+	}
+
+	key, err := hex.DecodeString(b.Key)
+	if err != nil {
+		return err
+	}
+
+	if len(key) != len(a.key) {
+		return errors.New("invalid bucketing key")
+	}
+	copy(a.key[:], key)
+
+	for _, ka := range b.Addresses {
+		netAddr, err := peermsg.NewNetAddress(ka.Addr, ka.LastSeen)
+		if err != nil {
+			continue
+		}
+
+		a.buckets[a.bucketOf(netAddr.IP)][ka.Addr] = ka
+	}
+
+	return nil
+}
+
+func (a *AddrManager) save() {
+	if a.file == "" {
+		return
+	}
+
+	a.lock.Lock()
+	b := &book{Key: hex.EncodeToString(a.key[:]), Addresses: make([]*KnownAddress, 0)}
+	for _, bucket := range a.buckets {
+		for _, ka := range bucket {
+			b.Addresses = append(b.Addresses, ka)
+		}
+	}
+
+	data, err := json.Marshal(b)
+	a.lock.Unlock()
+	if err == nil {
+		// write to a temporary file first, so that a crash can not corrupt the book
+		tmp := a.file + ".tmp"
+		if err = ioutil.WriteFile(tmp, data, 0600); err == nil {
+			err = os.Rename(tmp, a.file)
+		}
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "addrmgr",
+			"file":    a.file,
+			"error":   err,
+		}).Warnln("could not save address book")
+	}
+}
@@ -0,0 +1,71 @@
+package addrmgr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensure that a single network group can not take over the book.
+func TestGroupLimit(t *testing.T) {
+	a := New("")
+	now := time.Now().Unix()
+	for i := 0; i < 300; i++ {
+		a.Add(fmt.Sprintf("10.1.%d.%d:7000", i/250, i%250), now)
+	}
+
+	assert.Equal(t, bucketSize, a.Len())
+}
+
+// Ensure that the selected addresses are spread over the network groups.
+func TestSelectDiversity(t *testing.T) {
+	a := New("")
+	now := time.Now().Unix()
+	for i := 0; i < 20; i++ {
+		a.Add(fmt.Sprintf("10.1.0.%d:7000", i+1), now)
+	}
+	a.Add("10.2.0.1:7000", now)
+	a.Add("10.3.0.1:7000", now)
+
+	selected := a.Select(3, nil)
+	assert.Equal(t, 3, len(selected))
+	assert.Contains(t, selected, "10.2.0.1:7000")
+	assert.Contains(t, selected, "10.3.0.1:7000")
+
+	// excluded and bad addresses are skipped
+	for i := 0; i < maxFailures; i++ {
+		a.Attempt("10.2.0.1:7000")
+	}
+	selected = a.Select(30, map[string]bool{"10.3.0.1:7000": true})
+	assert.Equal(t, 20, len(selected))
+	assert.NotContains(t, selected, "10.2.0.1:7000")
+	assert.NotContains(t, selected, "10.3.0.1:7000")
+}
+
+// Ensure that the book survives a restart.
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrmgr")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "addrbook.json")
+	a := New(file)
+	a.Add("10.1.0.1:7000", time.Now().Unix())
+	a.Good("10.2.0.1:7000", []byte{1, 2, 3})
+	a.save()
+
+	b := New(file)
+	assert.Equal(t, a.key, b.key)
+	assert.Equal(t, 2, b.Len())
+	assert.NotEqual(t, int64(0), b.find("10.2.0.1:7000").LastSuccess)
+
+	// so are the identities of the nodes
+	accept := func(id []byte) bool { return true }
+	assert.Equal(t, []string{"10.2.0.1:7000"}, b.SelectNodes(10, nil, accept))
+	assert.Empty(t, b.SelectNodes(10, map[string]bool{"10.2.0.1:7000": true}, accept))
+}
@@ -10,12 +10,18 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"golang.org/x/crypto/ed25519"
 )
 
-// Handshake with another peer.
-func (p *Writer) Handshake() error {
+// Handshake with another peer. The secure channel is established first, then
+// the version messages are exchanged over it. bestHeight is the height of the
+// local chain tip, advertised to the peer.
+func (p *Writer) Handshake(identity ed25519.PrivateKey, bestHeight uint64) error {
+	if err := p.secureInitiate(identity); err != nil {
+		return err
+	}
 
-	if err := p.writeLocalMsgVersion(); err != nil {
+	if err := p.writeLocalMsgVersion(bestHeight); err != nil {
 		return err
 	}
 
@@ -27,11 +33,22 @@ func (p *Writer) Handshake() error {
 		return err
 	}
 
-	return p.writeVerAck()
+	if err := p.writeVerAck(); err != nil {
+		return err
+	}
+
+	p.negotiateFraming()
+	return nil
 }
 
-// Handshake with another peer.
-func (p *Reader) Handshake() error {
+// Handshake with another peer. The secure channel is established first, then
+// the version messages are exchanged over it. bestHeight is the height of the
+// local chain tip, advertised to the peer.
+func (p *Reader) Handshake(identity ed25519.PrivateKey, bestHeight uint64) error {
+	if err := p.secureRespond(identity); err != nil {
+		return err
+	}
+
 	if err := p.readRemoteMsgVersion(); err != nil {
 		return err
 	}
@@ -40,15 +57,29 @@ func (p *Reader) Handshake() error {
 		return err
 	}
 
-	if err := p.writeLocalMsgVersion(); err != nil {
+	if err := p.writeLocalMsgVersion(bestHeight); err != nil {
+		return err
+	}
+
+	if err := p.readVerAck(); err != nil {
 		return err
 	}
 
-	return p.readVerAck()
+	p.negotiateFraming()
+	return nil
 }
 
-func (p *Connection) writeLocalMsgVersion() error {
-	message, err := p.createVersionBuffer()
+// negotiateFraming switches to the highest frame format supported by both
+// ends. It is called once the last handshake message is exchanged.
+func (p *Connection) negotiateFraming() {
+	p.frameVersion = processing.CurrentFrameVersion
+	if p.remoteVersion.FrameVersion < p.frameVersion {
+		p.frameVersion = p.remoteVersion.FrameVersion
+	}
+}
+
+func (p *Connection) writeLocalMsgVersion(bestHeight uint64) error {
+	message, err := p.createVersionBuffer(bestHeight)
 	if err != nil {
 		return err
 	}
@@ -100,7 +131,12 @@ func (p *Connection) readRemoteMsgVersion() error {
 		return err
 	}
 
-	return verifyVersion(version.Version)
+	if err := verifyVersion(version.Version); err != nil {
+		return err
+	}
+
+	p.remoteVersion = version
+	return nil
 }
 
 func (p *Connection) addHeader(m *bytes.Buffer, topic topics.Topic) (*bytes.Buffer, error) {
@@ -171,9 +207,9 @@ func (p *Connection) writeVerAck() error {
 	return nil
 }
 
-func (p *Connection) createVersionBuffer() (*bytes.Buffer, error) {
+func (p *Connection) createVersionBuffer(bestHeight uint64) (*bytes.Buffer, error) {
 	version := protocol.NodeVer
-	message, err := newVersionMessageBuffer(version, protocol.FullNode)
+	message, err := newVersionMessageBuffer(version, protocol.FullNode, bestHeight, p.features)
 	if err != nil {
 		return nil, err
 	}
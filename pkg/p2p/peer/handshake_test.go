@@ -8,9 +8,12 @@ import (
 	_ "github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing/chainsync"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
 )
 
 func TestHandshake(t *testing.T) {
@@ -20,21 +23,35 @@ func TestHandshake(t *testing.T) {
 	counter := chainsync.NewCounter(eb)
 	client, srv := net.Pipe()
 
+	_, srvKey, _ := ed25519.GenerateKey(nil)
+	_, clientKey, _ := ed25519.GenerateKey(nil)
 	go func() {
 		peerReader, err := helper.StartPeerReader(srv, eb, rpcBus, counter, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		if err := peerReader.Accept(); err != nil {
+		if err := peerReader.Accept(srvKey, 42); err != nil {
 			t.Fatal(err)
 		}
 	}()
 
 	time.Sleep(500 * time.Millisecond)
-	pw := peer.NewWriter(client, protocol.TestNet, eb)
+	pw := peer.NewWriter(client, protocol.TestNet, processing.NewFlood(eb))
 	defer pw.Conn.Close()
-	if err := pw.Handshake(); err != nil {
+	if err := pw.Handshake(clientKey, 7); err != nil {
 		t.Fatal(err)
 	}
+
+	// both nodes support checksummed and compressed frames
+	assert.Equal(t, processing.FrameV2, pw.Framing())
+
+	// the identity of the peer is authenticated
+	assert.Equal(t, srvKey.Public(), pw.Remote().ID)
+
+	// and so are its services, tip and features
+	remote := pw.Remote().Version
+	assert.True(t, remote.ServesBlocks())
+	assert.Equal(t, uint64(42), remote.BestHeight)
+	assert.Equal(t, protocol.LocalFeatures, remote.Features)
 }
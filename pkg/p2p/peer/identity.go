@@ -0,0 +1,47 @@
+package peer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// LoadIdentity returns the long-term identity key of the node, stored in file.
+// A new key is generated and stored if the file does not exist. If file is
+// empty, an ephemeral key is returned.
+func LoadIdentity(file string) (ed25519.PrivateKey, error) {
+	if file == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		return key, ioutil.WriteFile(file, []byte(hex.EncodeToString(key)), 0600)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid identity key")
+	}
+
+	return ed25519.PrivateKey(key), nil
+}
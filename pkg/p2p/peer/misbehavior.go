@@ -0,0 +1,29 @@
+package peer
+
+import "net"
+
+// Ban scores of the protocol violations. A peer is banned once its score
+// reaches the ban threshold of the peer manager.
+const (
+	scoreUnexpected   = 10
+	scoreUnroutable   = 10
+	scoreMalformed    = 20
+	scoreRateLimited  = 5
+	scoreInvalidBlock = 100
+)
+
+// ScoreKeeper is implemented by the connections which keep track of the
+// misbehavior of the peer
+type ScoreKeeper interface {
+	Misbehaving(score uint32, reason string)
+}
+
+// penalizer returns a function adding to the ban score of the peer on the
+// other end of conn. Violations are only logged if conn does not keep a score.
+func penalizer(conn net.Conn) func(uint32, string) {
+	if keeper, ok := conn.(ScoreKeeper); ok {
+		return keeper.Misbehaving
+	}
+
+	return func(uint32, string) {}
+}
@@ -17,10 +17,17 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"golang.org/x/crypto/ed25519"
 )
 
 var readWriteTimeout = 60 * time.Second // Max idle time for a peer
 
+// LatencyRecorder is implemented by the connections which keep track of the
+// round trip time measured by the ping/pong protocol
+type LatencyRecorder interface {
+	RecordLatency(rtt time.Duration)
+}
+
 // Connection holds the TCP connection to another node, and it's known protocol magic.
 // The `net.Conn` is guarded by a mutex, to allow both multicast and one-to-one
 // communication between peers.
@@ -28,16 +35,46 @@ type Connection struct {
 	lock sync.Mutex
 	net.Conn
 	magic protocol.Magic
+	// the features advertised to the peer during the handshake
+	features protocol.Features
+
+	// the version message received during the handshake
+	remoteVersion *VersionMessage
+	// the frame format negotiated during the handshake
+	frameVersion processing.FrameVersion
+	// the secure channel established during the handshake, nil before
+	session *session
+	// the identity key proven by the peer during the handshake
+	remoteID ed25519.PublicKey
+}
+
+// Remote holds what is learned about a peer during the handshake
+type Remote struct {
+	Version *VersionMessage
+	// ID is the identity key of the peer
+	ID ed25519.PublicKey
+}
+
+// Shared holds the components shared by the readers of all the peers. It is
+// built once, when the node starts.
+type Shared struct {
+	Counter   *chainsync.Counter
+	Rejector  *processing.Rejector
+	Requests  *processing.RequestTracker
+	InvRelay  *processing.InvRelay
+	Dandelion *processing.Dandelion
+	// propagates the gossip to the peers
+	Broadcaster processing.Broadcaster
+	// limits of the traffic received from the peers
+	Limits *processing.Limits
 }
 
 // Writer abstracts all of the logic and fields needed to write messages to
 // other network nodes.
 type Writer struct {
 	*Connection
-	gossip     *processing.Gossip
-	gossipID   uint32
-	subscriber wire.EventSubscriber
-	// TODO: add service flag
+	gossip      *processing.Gossip
+	broadcaster processing.Broadcaster
 }
 
 // Reader abstracts all of the logic and fields needed to receive messages from
@@ -47,20 +84,20 @@ type Reader struct {
 	unmarshaller *messageUnmarshaller
 	router       *messageRouter
 	exitChan     chan<- struct{} // Way to kill the WriteLoop
-	// TODO: add service flag
 }
 
 // NewWriter returns a Writer. It will still need to be initialized by
-// subscribing to the gossip topic with a stream handler, and by running the WriteLoop
-// in a goroutine..
-func NewWriter(conn net.Conn, magic protocol.Magic, subscriber wire.EventSubscriber) *Writer {
+// running Serve in a goroutine, which registers the peer with the broadcaster
+// propagating the gossip.
+func NewWriter(conn net.Conn, magic protocol.Magic, broadcaster processing.Broadcaster) *Writer {
 	pw := &Writer{
 		Connection: &Connection{
-			Conn:  conn,
-			magic: magic,
+			Conn:     conn,
+			magic:    magic,
+			features: protocol.LocalFeatures | broadcaster.Features(),
 		},
-		gossip:     processing.NewGossip(magic),
-		subscriber: subscriber,
+		gossip:      processing.NewGossip(magic),
+		broadcaster: broadcaster,
 	}
 
 	return pw
@@ -68,15 +105,19 @@ func NewWriter(conn net.Conn, magic protocol.Magic, subscriber wire.EventSubscri
 
 // NewReader returns a Reader. It will still need to be initialized by
 // running ReadLoop in a goroutine.
-func NewReader(conn net.Conn, magic protocol.Magic, dupeMap *dupemap.DupeMap, publisher wire.EventPublisher, rpcBus *wire.RPCBus, counter *chainsync.Counter, responseChan chan<- *bytes.Buffer, exitChan chan<- struct{}) (*Reader, error) {
+func NewReader(conn net.Conn, magic protocol.Magic, dupeMap *dupemap.DupeMap, publisher wire.EventPublisher, rpcBus *wire.RPCBus, shared *Shared, responseChan chan<- *bytes.Buffer, exitChan chan<- struct{}) (*Reader, error) {
 	pconn := &Connection{
-		Conn:  conn,
-		magic: magic,
+		Conn:     conn,
+		magic:    magic,
+		features: protocol.LocalFeatures | shared.Broadcaster.Features(),
 	}
 
 	_, db := heavy.CreateDBConnection()
 
-	dataRequestor := processing.NewDataRequestor(db, rpcBus, responseChan)
+	var onRTT func(time.Duration)
+	if recorder, ok := conn.(LatencyRecorder); ok {
+		onRTT = recorder.RecordLatency
+	}
 
 	reader := &Reader{
 		Connection:   pconn,
@@ -86,36 +127,57 @@ func NewReader(conn net.Conn, magic protocol.Magic, dupeMap *dupemap.DupeMap, pu
 			publisher:       publisher,
 			dupeMap:         dupeMap,
 			blockHashBroker: processing.NewBlockHashBroker(db, responseChan),
-			synchronizer:    chainsync.NewChainSynchronizer(publisher, rpcBus, responseChan, counter),
-			dataRequestor:   dataRequestor,
+			synchronizer:    chainsync.NewChainSynchronizer(publisher, rpcBus, responseChan, shared.Counter),
+			dataRequestor:   processing.NewDataRequestor(db, rpcBus, responseChan, shared.Requests),
 			dataBroker:      processing.NewDataBroker(db, rpcBus, responseChan),
+			compactBlocks:   processing.NewCompactBlocks(rpcBus, responseChan),
+			rejector:        shared.Rejector,
+			requests:        shared.Requests,
+			invRelay:        shared.InvRelay,
+			dandelion:       shared.Dandelion,
+			broadcaster:     shared.Broadcaster,
+			limiter:         processing.NewRateLimiter(shared.Limits),
+			addrBroker:      processing.NewAddrBroker(rpcBus, responseChan),
+			pinger:          processing.NewPinger(responseChan, onRTT),
+			responseChan:    responseChan,
+			penalize:        penalizer(conn),
 			peerInfo:        conn.RemoteAddr().String(),
 		},
 	}
 
-	// On each new connection the node sends topics.Mempool to retrieve mempool
-	// txs from the new peer
-	go func() {
-		if err := dataRequestor.RequestMempoolItems(); err != nil {
-			log.WithFields(log.Fields{
-				"process": "peer",
-				"error":   err,
-			}).Warnln("error sending topics.Mempool message")
-		}
-	}()
-
 	return reader, nil
 }
 
-// ReadMessage reads from the connection
+// ReadMessage reads a frame from the connection, in the negotiated format,
+// decrypts it once the secure channel is established, and decompresses it.
 func (c *Connection) ReadMessage() ([]byte, error) {
 	// COBS  c.reader.ReadBytes(0x00)
-	return processing.ReadFrame(c.Conn)
+	b, compressed, err := processing.ReadFrameFlags(c.Conn, c.frameVersion)
+	if err != nil {
+		if err == processing.ErrChecksumMismatch && c.session != nil {
+			// keep the nonces in sync
+			c.session.skip()
+		}
+		return nil, err
+	}
+
+	if c.session != nil {
+		b, err = c.session.open(b, frameData(compressed))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if compressed {
+		return processing.Decompress(b)
+	}
+
+	return b, nil
 }
 
 // Connect will perform the protocol handshake with the peer. If successful
-func (p *Writer) Connect() error {
-	if err := p.Handshake(); err != nil {
+func (p *Writer) Connect(identity ed25519.PrivateKey, bestHeight uint64) error {
+	if err := p.Handshake(identity, bestHeight); err != nil {
 		p.Conn.Close()
 		return err
 	}
@@ -124,8 +186,8 @@ func (p *Writer) Connect() error {
 }
 
 // Accept will perform the protocol handshake with the peer.
-func (p *Reader) Accept() error {
-	if err := p.Handshake(); err != nil {
+func (p *Reader) Accept(identity ed25519.PrivateKey, bestHeight uint64) error {
+	if err := p.Handshake(identity, bestHeight); err != nil {
 		p.Conn.Close()
 		return err
 	}
@@ -133,54 +195,91 @@ func (p *Reader) Accept() error {
 	return nil
 }
 
-// Serve utilizes two different methods for writing to the open connection
-func (w *Writer) Serve(writeQueueChan <-chan *bytes.Buffer, exitChan chan struct{}) {
+// Serve writes the gossip and the messages of the writeQueue to the open
+// connection, by order of priority
+func (w *Writer) Serve(writeQueueChan chan *bytes.Buffer, exitChan chan struct{}) {
 
-	defer w.onDisconnect()
+	defer w.onDisconnect(writeQueueChan)
 
-	// Any gossip topics are written into interrupt-driven ringBuffer
-	// Single-consumer pushes messages to the socket
-	w.gossipID = w.subscriber.SubscribeStream(string(topics.Gossip), w.Connection)
+	// the gossip reaches the peer through the broadcaster, either as frames
+	// or on the writeQueue
+	queue := processing.NewOutboundQueue()
+	var features protocol.Features
+	if w.remoteVersion != nil {
+		features = w.remoteVersion.Features
+	}
+	w.broadcaster.AddPeer(w.remoteID, features, queue, writeQueueChan)
 
-	// writeQueue - FIFO queue
-	// writeLoop pushes first-in message to the socket
-	w.writeLoop(writeQueueChan, exitChan)
+	// writeQueue - FIFO queue, sorted into the outbound queue by priority
+	// writeLoop pushes the highest priority message to the socket
+	w.writeLoop(writeQueueChan, queue, exitChan)
 }
 
-func (w *Writer) onDisconnect() {
+func (w *Writer) onDisconnect(writeQueueChan chan *bytes.Buffer) {
 	log.Infof("Connection to %s terminated", w.Connection.RemoteAddr().String())
 	w.Conn.Close()
-	w.subscriber.Unsubscribe(string(topics.Gossip), w.gossipID)
+	w.broadcaster.RemovePeer(writeQueueChan)
 }
 
-func (w *Writer) writeLoop(writeQueueChan <-chan *bytes.Buffer, exitChan chan struct{}) {
+func (w *Writer) writeLoop(writeQueueChan <-chan *bytes.Buffer, queue *processing.OutboundQueue, exitChan chan struct{}) {
 
 	for {
 		select {
 		case buf := <-writeQueueChan:
-			processed, err := w.gossip.Process(buf)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"process": "peer",
-					"error":   err,
-				}).Warnln("error processing outgoing message")
-				continue
+			queue.Push(buf)
+		case <-queue.Ready():
+		case <-exitChan:
+			return
+		}
+
+		// the messages queued in the meantime are taken in before each
+		// write, so that a burst of low priority messages does not delay the
+		// higher priority ones
+		for {
+			drainWriteQueue(writeQueueChan, queue)
+			buf := queue.Pop()
+			if buf == nil {
+				break
 			}
 
-			if _, err := w.Connection.Write(processed.Bytes()); err != nil {
+			if err := w.write(buf); err != nil {
 				log.WithFields(log.Fields{
 					"process": "peer",
 					"queue":   "writequeue",
 					"error":   err,
 				}).Warnln("error writing message")
 				exitChan <- struct{}{}
+				break
 			}
-		case <-exitChan:
-			return
 		}
 	}
 }
 
+// write a message from the outbound queue to the connection. The messages which
+// can not be framed are skipped.
+func (w *Writer) write(buf *bytes.Buffer) error {
+	processed, err := w.gossip.Process(buf)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "peer",
+			"error":   err,
+		}).Warnln("error processing outgoing message")
+		return nil
+	}
+
+	_, err = w.Connection.Write(processed.Bytes())
+	return err
+}
+
+// drainWriteQueue moves the messages waiting in the writeQueue to the outbound
+// queue. It takes no more than the messages waiting when it is called, so that
+// the writes are not held up by a busy writeQueue.
+func drainWriteQueue(writeQueueChan <-chan *bytes.Buffer, queue *processing.OutboundQueue) {
+	for i := len(writeQueueChan); i > 0; i-- {
+		queue.Push(<-writeQueueChan)
+	}
+}
+
 // ReadLoop will block on the read until a message is read, or until the deadline
 // is reached. Should be called in a go-routine, after a successful handshake with
 // a peer. Eventual duplicated messages are silently discarded.
@@ -190,16 +289,77 @@ func (p *Reader) ReadLoop() {
 		p.exitChan <- struct{}{}
 	}()
 
+	// the items requested from the peer are requested from others, once it is
+	// gone
+	defer p.router.requests.Forget(p.router.responseChan)
+
+	// the items the peer knows about are not advertised to it
+	p.router.invRelay.AddPeer(p.router.responseChan)
+	defer p.router.invRelay.RemovePeer(p.router.responseChan)
+
+	// the messages exchanged with the peer depend on what it advertised
+	p.router.remote = p.remoteVersion
+	if p.remoteVersion != nil {
+		p.router.dandelion.AddPeer(p.router.responseChan, p.remoteVersion.Features)
+		defer p.router.dandelion.RemovePeer(p.router.responseChan)
+		defer p.router.synchronizer.Forget()
+		if err := p.router.synchronizer.Advertised(p.remoteVersion.BestHeight, p.remoteVersion.ServesBlocks(), p.remoteVersion.Features); err != nil {
+			log.WithFields(log.Fields{
+				"process": "peer",
+				"error":   err,
+			}).Warnln("error syncing with the peer tip")
+		}
+	}
+
+	go p.greet()
+
+	// keep the connection alive while it is idle, and drop the peer if it
+	// stops answering
+	quitChan := make(chan struct{})
+	defer close(quitChan)
+	if p.router.supports(protocol.FeaturePing) {
+		go func() {
+			if err := p.router.pinger.Run(quitChan); err != nil {
+				log.WithFields(log.Fields{
+					"process": "peer",
+					"address": p.Addr(),
+					"error":   err,
+				}).Warnln("disconnecting peer")
+				_ = p.Conn.Close()
+			}
+		}()
+	}
+
 	for {
 		// Refresh the read deadline
 		p.Conn.SetReadDeadline(time.Now().Add(readWriteTimeout))
 
 		b, err := p.ReadMessage()
+
+		// stay within the bandwidth limits, by holding off the next read
+		if wait := p.router.limiter.Throttle(len(b), time.Now()); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		if err == processing.ErrChecksumMismatch || err == processing.ErrDecompression {
+			log.WithFields(log.Fields{
+				"process": "peer",
+				"error":   err,
+			}).Warnln("error reading message")
+			p.router.penalize(scoreMalformed, "corrupted frame")
+			continue
+		}
+
 		if err != nil {
 			log.WithFields(log.Fields{
 				"process": "peer",
 				"error":   err,
 			}).Warnln("error reading message")
+			// the stream may have been tampered with, it can not be trusted
+			// any longer
+			if err == ErrDecryption {
+				p.router.penalize(scoreMalformed, "unauthenticated frame")
+			}
 			return
 		}
 
@@ -209,6 +369,7 @@ func (p *Reader) ReadLoop() {
 				"process": "peer",
 				"error":   err,
 			}).Warnln("error unmarshalling message")
+			p.router.penalize(scoreMalformed, "malformed frame")
 			continue
 		}
 
@@ -216,6 +377,29 @@ func (p *Reader) ReadLoop() {
 	}
 }
 
+// greet sends the requests made on each new connection
+func (p *Reader) greet() {
+	// retrieve the mempool txs from the new peer
+	if err := p.router.dataRequestor.RequestMempoolItems(); err != nil {
+		log.WithFields(log.Fields{
+			"process": "peer",
+			"error":   err,
+		}).Warnln("error sending topics.Mempool message")
+	}
+
+	// ask for the addresses known by the new peer as well
+	if !p.router.supports(protocol.FeatureAddr) {
+		return
+	}
+
+	if err := p.router.addrBroker.RequestAddresses(); err != nil {
+		log.WithFields(log.Fields{
+			"process": "peer",
+			"error":   err,
+		}).Warnln("error sending topics.GetAddr message")
+	}
+}
+
 // Read the topic bytes off r, and return them as a topics.Topic.
 func extractTopic(r io.Reader) (topics.Topic, error) {
 	var cmdBuf [topics.Size]byte
@@ -236,15 +420,75 @@ func extractMagic(r io.Reader) (protocol.Magic, error) {
 	return protocol.Magic(magic), nil
 }
 
-// Write a message to the connection.
+// Write a message to the connection. b is a frame as built by
+// processing.WriteFrame, which is compressed, encrypted and converted to the
+// negotiated format.
 // Conn needs to be locked, as this function can be called both by the WriteLoop,
 // and by the writer on the ring buffer.
 func (c *Connection) Write(b []byte) (int, error) {
 	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	frame := b
+	if c.session != nil || c.frameVersion != processing.FrameV0 {
+		payload, err := processing.FramePayload(b)
+		if err != nil {
+			return 0, err
+		}
+
+		// the payloads are compressed before being sealed, as the ciphertext
+		// does not compress
+		compressed := false
+		if c.frameVersion >= processing.FrameV2 {
+			payload, compressed = processing.Compress(payload)
+		}
+
+		// the payloads are sealed in the order they are written, and the
+		// compressed flag is authenticated along with them
+		if c.session != nil {
+			payload = c.session.seal(payload, frameData(compressed))
+		}
+
+		buf, err := processing.WriteFrameFlags(bytes.NewBuffer(payload), c.frameVersion, compressed)
+		if err != nil {
+			return 0, err
+		}
+		frame = buf.Bytes()
+	}
+
 	c.Conn.SetWriteDeadline(time.Now().Add(readWriteTimeout))
-	n, err := c.Conn.Write(b)
-	c.lock.Unlock()
-	return n, err
+	if _, err := c.Conn.Write(frame); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// Framing returns the frame format negotiated with the peer
+func (c *Connection) Framing() processing.FrameVersion {
+	return c.frameVersion
+}
+
+// ShareSession hands the state negotiated by other during the handshake over
+// to this Connection: the secure channel, the frame format, and what is known
+// about the peer. It is used by the Reader or Writer which did not perform the
+// handshake, before any message is exchanged.
+func (c *Connection) ShareSession(other *Connection) {
+	c.session = other.session
+	c.frameVersion = other.frameVersion
+	c.remoteVersion = other.remoteVersion
+	c.remoteID = other.remoteID
+}
+
+// RTT returns the last round trip time measured with the peer, or zero if
+// none was measured yet
+func (p *Reader) RTT() time.Duration {
+	return p.router.pinger.RTT()
+}
+
+// Remote returns what is known about the peer, once the handshake is performed
+func (c *Connection) Remote() *Remote {
+	return &Remote{Version: c.remoteVersion, ID: c.remoteID}
 }
 
 // Addr returns the peer's address as a string.
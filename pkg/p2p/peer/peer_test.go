@@ -89,7 +89,7 @@ func TestWriteLoop(t *testing.T) {
 	buf := makeAgreementBuffer(10)
 	go func() {
 		responseChan := make(chan *bytes.Buffer)
-		writer := peer.NewWriter(client, protocol.TestNet, bus)
+		writer := peer.NewWriter(client, protocol.TestNet, processing.NewFlood(bus))
 		go writer.Serve(responseChan, make(chan struct{}, 1))
 
 		bufCopy := *buf
@@ -148,7 +148,7 @@ func makeAgreementBuffer(keyAmount int) *bytes.Buffer {
 
 func addPeer(bus *wire.EventBus, receiveFunc func(net.Conn)) *peer.Writer {
 	client, srv := net.Pipe()
-	pw := peer.NewWriter(client, protocol.TestNet, bus)
+	pw := peer.NewWriter(client, protocol.TestNet, processing.NewFlood(bus))
 	go receiveFunc(srv)
 	return pw
 }
@@ -0,0 +1,338 @@
+package peermgr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+)
+
+// Ban prevents any connection from and to an IP, or with the peer holding an
+// identity key, until it expires
+type Ban struct {
+	// Target is either an IP, or a hex encoded peer ID
+	Target string `json:"target"`
+	Until  int64  `json:"until"`
+	Reason string `json:"reason"`
+}
+
+// banList holds the bans by target, and stores them on disk on every change.
+// It is guarded by the lock of the Manager.
+type banList struct {
+	file string
+	bans map[string]Ban
+}
+
+func newBanList(file string) *banList {
+	b := &banList{file: file, bans: make(map[string]Ban)}
+	if file == "" {
+		return b
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithFields(log.Fields{
+				"process": "peermgr",
+				"file":    file,
+				"error":   err,
+			}).Warnln("could not load ban list")
+		}
+		return b
+	}
+
+	bans := make([]Ban, 0)
+	if err := json.Unmarshal(data, &bans); err != nil {
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"file":    file,
+			"error":   err,
+		}).Warnln("could not load ban list")
+		return b
+	}
+
+	for _, ban := range bans {
+		b.bans[ban.Target] = ban
+	}
+
+	return b
+}
+
+// banned checks if target is banned, dropping the ban if it expired
+func (b *banList) banned(target string, now time.Time) bool {
+	ban, ok := b.bans[target]
+	if !ok {
+		return false
+	}
+
+	if now.Unix() >= ban.Until {
+		delete(b.bans, target)
+		b.save()
+		return false
+	}
+
+	return true
+}
+
+func (b *banList) add(ban Ban) {
+	b.bans[ban.Target] = ban
+	b.save()
+}
+
+func (b *banList) remove(target string) bool {
+	if _, ok := b.bans[target]; !ok {
+		return false
+	}
+
+	delete(b.bans, target)
+	b.save()
+	return true
+}
+
+// list returns the active bans, sorted by target
+func (b *banList) list(now time.Time) []Ban {
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		if now.Unix() < ban.Until {
+			bans = append(bans, ban)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Target < bans[j].Target })
+	return bans
+}
+
+func (b *banList) save() {
+	if b.file == "" {
+		return
+	}
+
+	data, err := json.Marshal(b.list(time.Now()))
+	if err == nil {
+		// write to a temporary file first, so that a crash can not corrupt the list
+		tmp := b.file + ".tmp"
+		if err = ioutil.WriteFile(tmp, data, 0600); err == nil {
+			err = os.Rename(tmp, b.file)
+		}
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"file":    b.file,
+			"error":   err,
+		}).Warnln("could not save ban list")
+	}
+}
+
+// Ban an IP or a peer ID for the given duration, disconnecting all of the
+// matching peers
+func (m *Manager) Ban(target string, duration time.Duration, reason string) error {
+	if !validTarget(target) {
+		return errors.New("invalid IP address or peer ID")
+	}
+
+	m.lock.Lock()
+	m.bans.add(Ban{Target: target, Until: time.Now().Add(duration).Unix(), Reason: reason})
+	conns := make([]*countingConn, 0)
+	for cc, p := range m.peers {
+		if hostOf(cc.RemoteAddr().String()) == target || p.id() == target {
+			conns = append(conns, cc)
+		}
+	}
+	m.lock.Unlock()
+
+	log.WithFields(log.Fields{
+		"process":  "peermgr",
+		"target":   target,
+		"duration": duration,
+		"reason":   reason,
+	}).Warnln("peer banned")
+
+	for _, cc := range conns {
+		_ = cc.Close()
+	}
+
+	return nil
+}
+
+// Unban an IP or a peer ID. It returns false if the target was not banned.
+func (m *Manager) Unban(target string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.bans.remove(target)
+}
+
+func validTarget(target string) bool {
+	if net.ParseIP(target) != nil {
+		return true
+	}
+
+	id, err := hex.DecodeString(target)
+	return err == nil && len(id) == ed25519.PublicKeySize
+}
+
+// Bans returns the active bans
+func (m *Manager) Bans() []Ban {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.bans.list(time.Now())
+}
+
+// misbehaving adds score to the ban score of a peer, and bans both the IP and
+// the ID of the peer once the threshold is reached
+func (m *Manager) misbehaving(cc *countingConn, score uint32, reason string) {
+	m.lock.Lock()
+	p, ok := m.peers[cc]
+	if !ok {
+		m.lock.Unlock()
+		return
+	}
+
+	p.score += score
+	total := p.score
+	id := p.id()
+	m.lock.Unlock()
+
+	log.WithFields(log.Fields{
+		"process": "peermgr",
+		"address": p.addr,
+		"score":   total,
+		"reason":  reason,
+	}).Debugln("peer misbehaving")
+
+	if total >= m.cfg.BanThreshold {
+		if id != "" {
+			_ = m.Ban(id, m.cfg.BanDuration, reason)
+		}
+		_ = m.Ban(hostOf(cc.RemoteAddr().String()), m.cfg.BanDuration, reason)
+	}
+}
+
+func (m *Manager) onGetBans(r wire.Req) {
+	bans := m.Bans()
+
+	w := new(bytes.Buffer)
+	if err := encoding.WriteVarInt(w, uint64(len(bans))); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	for _, ban := range bans {
+		if err := ban.Encode(w); err != nil {
+			r.ErrChan <- err
+			return
+		}
+	}
+
+	r.RespChan <- *w
+}
+
+// onAddBan bans an IP or a peer ID.
+// Param 1: string IP or hex encoded peer ID
+// Param 2: uint64 duration in seconds, zero for the configured duration
+// Param 3: string reason
+func (m *Manager) onAddBan(r wire.Req) {
+	var target, reason string
+	var seconds uint64
+	if err := encoding.ReadString(&r.Params, &target); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	if err := encoding.ReadUint64(&r.Params, binary.LittleEndian, &seconds); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	if err := encoding.ReadString(&r.Params, &reason); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	duration := m.cfg.BanDuration
+	if seconds > 0 {
+		duration = time.Duration(seconds) * time.Second
+	}
+
+	if err := m.Ban(target, duration, reason); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	r.RespChan <- bytes.Buffer{}
+}
+
+// onRemoveBan lifts the ban of an IP or a peer ID.
+// Param 1: string IP or hex encoded peer ID
+func (m *Manager) onRemoveBan(r wire.Req) {
+	var target string
+	if err := encoding.ReadString(&r.Params, &target); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	if !m.Unban(target) {
+		r.ErrChan <- errors.New("not banned")
+		return
+	}
+
+	r.RespChan <- bytes.Buffer{}
+}
+
+// Encode a Ban struct and write it to w.
+func (b Ban) Encode(w io.Writer) error {
+	if err := encoding.WriteString(w, b.Target); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint64(w, binary.LittleEndian, uint64(b.Until)); err != nil {
+		return err
+	}
+
+	return encoding.WriteString(w, b.Reason)
+}
+
+// Decode a Ban struct from r.
+func (b *Ban) Decode(r io.Reader) error {
+	if err := encoding.ReadString(r, &b.Target); err != nil {
+		return err
+	}
+
+	var until uint64
+	if err := encoding.ReadUint64(r, binary.LittleEndian, &until); err != nil {
+		return err
+	}
+	b.Until = int64(until)
+
+	return encoding.ReadString(r, &b.Reason)
+}
+
+// DecodeBans decodes a list of bans, as returned by the wire.GetBans method.
+func DecodeBans(r *bytes.Buffer) ([]Ban, error) {
+	lBans, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]Ban, lBans)
+	for i := range bans {
+		if err := bans[i].Decode(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return bans, nil
+}
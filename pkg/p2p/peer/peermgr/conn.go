@@ -0,0 +1,60 @@
+package peermgr
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// countingConn wraps a net.Conn to keep track of the bytes exchanged with a
+// peer, and to notify the Manager when the connection is closed. A connection
+// is closed by either the peer Reader or Writer, whichever terminates first.
+type countingConn struct {
+	net.Conn
+	bytesIn  uint64
+	bytesOut uint64
+	// last round trip time, in nanoseconds
+	rtt int64
+
+	closeOnce   sync.Once
+	onClose     func()
+	onMisbehave func(score uint32, reason string)
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(&c.bytesIn, uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(&c.bytesOut, uint64(n))
+	return n, err
+}
+
+// Close the underlying connection. The Manager is notified only once.
+func (c *countingConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.onClose)
+	return err
+}
+
+// RecordLatency implements peer.LatencyRecorder
+func (c *countingConn) RecordLatency(rtt time.Duration) {
+	atomic.StoreInt64(&c.rtt, int64(rtt))
+}
+
+// Misbehaving implements peer.ScoreKeeper
+func (c *countingConn) Misbehaving(score uint32, reason string) {
+	c.onMisbehave(score, reason)
+}
+
+func (c *countingConn) stats() (uint64, uint64) {
+	return atomic.LoadUint64(&c.bytesIn), atomic.LoadUint64(&c.bytesOut)
+}
+
+func (c *countingConn) latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.rtt))
+}
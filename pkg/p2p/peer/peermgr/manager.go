@@ -0,0 +1,564 @@
+package peermgr
+
+import (
+	"encoding/hex"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// maintainInterval is the time between two checks of the outbound peers
+	maintainInterval = 5 * time.Second
+	// minBackoff and maxBackoff bound the delay before redialing an address
+	minBackoff = 1 * time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+const (
+	defaultTargetOutbound  = 8
+	defaultOverlayOutbound = 8
+	defaultMaxInbound      = 64
+	defaultMaxPerIP        = 4
+	defaultBanThreshold    = 100
+	defaultBanDuration     = 24 * time.Hour
+)
+
+// Config holds the connection limits of the Manager. Zero values are replaced
+// by the defaults.
+type Config struct {
+	// TargetOutbound is the amount of outbound peers the Manager maintains
+	TargetOutbound int
+	// OverlayOutbound is the maximum amount of outbound peers dialed on top
+	// of the target, to fill the routing table of the overlay
+	OverlayOutbound int
+	// MaxInbound is the maximum amount of inbound peers
+	MaxInbound int
+	// MaxPerIP is the maximum amount of peers sharing the same IP
+	MaxPerIP int
+	// BanThreshold is the ban score at which a misbehaving peer is banned
+	BanThreshold uint32
+	// BanDuration is the duration of the automatic bans
+	BanDuration time.Duration
+	// BanList is the file storing the bans. Bans are not persisted if empty.
+	BanList string
+}
+
+// Handler performs the protocol handshake on a new connection, and then spawns
+// the peer Reader and Writer. It returns what was learned about the peer
+// during the handshake. The connection is closed by the handler if the
+// handshake fails.
+type Handler func(conn net.Conn, inbound bool) (*peer.Remote, error)
+
+// Dialer opens a connection to the given address
+type Dialer func(addr string) (net.Conn, error)
+
+// AddressBook is a source of outbound candidates, used when the addresses
+// given to the Manager are not enough to reach the outbound target.
+type AddressBook interface {
+	// Select returns up to n addresses which are not in exclude
+	Select(n int, exclude map[string]bool) []string
+	// Attempt marks a dial attempt to an address
+	Attempt(addr string)
+	// Good marks a successful handshake with an address, and the identity
+	// key of its node
+	Good(addr string, id []byte)
+	// SelectNodes returns up to n addresses which are not in exclude, among
+	// the ones of the nodes whose identity key is known and accepted
+	SelectNodes(n int, exclude map[string]bool, accept func(id []byte) bool) []string
+}
+
+// Overlay is a structured broadcast overlay, which needs connections to
+// specific nodes to fill its routing table.
+type Overlay interface {
+	// Wants returns true if the node with the given identity key would fill
+	// the routing table
+	Wants(id []byte) bool
+}
+
+type connectedPeer struct {
+	addr    string
+	inbound bool
+	since   time.Time
+	remote  *peer.Remote
+	conn    *countingConn
+	// score accumulates the protocol violations of the peer
+	score uint32
+}
+
+// candidate is an address we want to keep an outbound connection with
+type candidate struct {
+	attempts  uint
+	next      time.Time
+	connected bool
+	// a dial is in progress
+	pending bool
+	// the candidate comes from the address book, and is dropped on failure
+	fromBook bool
+	// the candidate is dialed to fill the routing table of the overlay, and
+	// does not count towards the outbound target
+	forOverlay bool
+}
+
+// Manager keeps track of the connected peers. It maintains a target amount of
+// outbound connections, redialing the known addresses with an exponential
+// backoff, and enforces the limits on inbound connections.
+type Manager struct {
+	lock       sync.Mutex
+	cfg        Config
+	peers      map[*countingConn]*connectedPeer
+	candidates map[string]*candidate
+	bans       *banList
+
+	dial    Dialer
+	handler Handler
+	book    AddressBook
+	overlay Overlay
+
+	quitChan chan struct{}
+	closed   bool
+}
+
+// New returns a Manager. Outbound connections are only made once Start is called.
+func New(cfg Config, dial Dialer, handler Handler) *Manager {
+	if cfg.TargetOutbound <= 0 {
+		cfg.TargetOutbound = defaultTargetOutbound
+	}
+
+	if cfg.OverlayOutbound <= 0 {
+		cfg.OverlayOutbound = defaultOverlayOutbound
+	}
+
+	if cfg.MaxInbound <= 0 {
+		cfg.MaxInbound = defaultMaxInbound
+	}
+
+	if cfg.MaxPerIP <= 0 {
+		cfg.MaxPerIP = defaultMaxPerIP
+	}
+
+	if cfg.BanThreshold == 0 {
+		cfg.BanThreshold = defaultBanThreshold
+	}
+
+	if cfg.BanDuration <= 0 {
+		cfg.BanDuration = defaultBanDuration
+	}
+
+	return &Manager{
+		cfg:        cfg,
+		peers:      make(map[*countingConn]*connectedPeer),
+		candidates: make(map[string]*candidate),
+		bans:       newBanList(cfg.BanList),
+		dial:       dial,
+		handler:    handler,
+		quitChan:   make(chan struct{}),
+	}
+}
+
+// AddAddresses adds addresses to the list of outbound candidates
+func (m *Manager) AddAddresses(addrs ...string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+
+		if _, ok := m.candidates[addr]; !ok {
+			m.candidates[addr] = &candidate{}
+		}
+	}
+}
+
+// UseAddressBook sets the address book the Manager picks candidates from,
+// and reports the outcome of its dials to.
+func (m *Manager) UseAddressBook(book AddressBook) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.book = book
+}
+
+// UseOverlay sets the overlay whose routing table is filled with outbound
+// connections to the nodes of the address book.
+func (m *Manager) UseOverlay(overlay Overlay) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.overlay = overlay
+}
+
+// Start maintaining the outbound connections in a goroutine. The same
+// goroutine serves the wire.GetPeerInfo requests, and the requests managing
+// the bans.
+func (m *Manager) Start() {
+	go func() {
+		ticker := time.NewTicker(maintainInterval)
+		defer ticker.Stop()
+
+		m.maintain()
+		for {
+			select {
+			case <-ticker.C:
+				m.maintain()
+			case r := <-wire.GetPeerInfoChan:
+				m.onGetPeerInfo(r)
+			case r := <-wire.GetBansChan:
+				m.onGetBans(r)
+			case r := <-wire.AddBanChan:
+				m.onAddBan(r)
+			case r := <-wire.RemoveBanChan:
+				m.onRemoveBan(r)
+			case <-m.quitChan:
+				return
+			}
+		}
+	}()
+}
+
+// maintain dials as many candidates as needed to reach the outbound target,
+// and to fill the routing table of the overlay
+func (m *Manager) maintain() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	now := time.Now()
+	m.dialOutbound(now)
+	m.fillOverlay(now)
+}
+
+// dialOutbound dials as many candidates as needed to reach the outbound
+// target. Must be called with the lock held.
+func (m *Manager) dialOutbound(now time.Time) {
+	missing := m.cfg.TargetOutbound
+	for _, c := range m.candidates {
+		if !c.forOverlay && (c.connected || c.pending) {
+			missing--
+		}
+	}
+
+	for addr, c := range m.candidates {
+		if missing <= 0 {
+			return
+		}
+
+		if c.connected || c.pending || now.Before(c.next) || m.bans.banned(hostOf(addr), now) {
+			continue
+		}
+
+		c.pending = true
+		missing--
+		go m.connect(addr)
+	}
+
+	if missing <= 0 || m.book == nil {
+		return
+	}
+
+	for _, addr := range m.book.Select(missing, m.excluded()) {
+		if m.bans.banned(hostOf(addr), now) {
+			continue
+		}
+
+		m.candidates[addr] = &candidate{pending: true, fromBook: true}
+		go m.connect(addr)
+	}
+}
+
+// fillOverlay dials the nodes of the address book wanted by the overlay, up
+// to the overlay outbound limit. Must be called with the lock held.
+func (m *Manager) fillOverlay(now time.Time) {
+	if m.overlay == nil || m.book == nil {
+		return
+	}
+
+	missing := m.cfg.OverlayOutbound
+	for _, c := range m.candidates {
+		if c.forOverlay && (c.connected || c.pending) {
+			missing--
+		}
+	}
+
+	if missing <= 0 {
+		return
+	}
+
+	for _, addr := range m.book.SelectNodes(missing, m.excluded(), m.overlay.Wants) {
+		if m.bans.banned(hostOf(addr), now) {
+			continue
+		}
+
+		m.candidates[addr] = &candidate{pending: true, fromBook: true, forOverlay: true}
+		go m.connect(addr)
+	}
+}
+
+// excluded returns the addresses which are already candidates or connected.
+// Must be called with the lock held.
+func (m *Manager) excluded() map[string]bool {
+	exclude := make(map[string]bool, len(m.candidates)+len(m.peers))
+	for addr := range m.candidates {
+		exclude[addr] = true
+	}
+
+	for _, p := range m.peers {
+		exclude[p.addr] = true
+	}
+
+	return exclude
+}
+
+func (m *Manager) connect(addr string) {
+	m.lock.Lock()
+	book := m.book
+	m.lock.Unlock()
+	if book != nil {
+		book.Attempt(addr)
+	}
+
+	conn, err := m.dial(addr)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"address": addr,
+			"error":   err,
+		}).Debugln("could not dial peer")
+		m.lock.Lock()
+		m.failed(addr)
+		m.lock.Unlock()
+		return
+	}
+
+	m.lock.Lock()
+	if m.closed || !m.allowIP(conn) || m.bans.banned(hostOf(addr), time.Now()) {
+		m.failed(addr)
+		m.lock.Unlock()
+		_ = conn.Close()
+		return
+	}
+
+	cc := m.track(conn, addr, false)
+	c := m.candidates[addr]
+	c.pending = false
+	c.connected = true
+	m.lock.Unlock()
+
+	m.handshake(cc, false)
+}
+
+// Accept an inbound connection, unless it exceeds the connection limits
+func (m *Manager) Accept(conn net.Conn) {
+	m.lock.Lock()
+	if m.closed || m.countInbound() >= m.cfg.MaxInbound || !m.allowIP(conn) ||
+		m.bans.banned(hostOf(conn.RemoteAddr().String()), time.Now()) {
+		m.lock.Unlock()
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"address": conn.RemoteAddr().String(),
+		}).Debugln("inbound connection refused")
+		_ = conn.Close()
+		return
+	}
+
+	cc := m.track(conn, conn.RemoteAddr().String(), true)
+	m.lock.Unlock()
+
+	m.handshake(cc, true)
+}
+
+func (m *Manager) handshake(cc *countingConn, inbound bool) {
+	remote, err := m.handler(cc, inbound)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"address": cc.RemoteAddr().String(),
+			"error":   err,
+		}).Warnln("problem performing handshake")
+		// ensure the peer is released, in case the handler did not close it
+		_ = cc.Close()
+		return
+	}
+
+	// the outbound slots are kept for the peers we can sync from
+	if !inbound && remote != nil && remote.Version != nil && !remote.Version.ServesBlocks() {
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"address": cc.RemoteAddr().String(),
+		}).Debugln("outbound peer does not serve blocks")
+		_ = cc.Close()
+		return
+	}
+
+	m.lock.Lock()
+	if remote != nil && m.bans.banned(hex.EncodeToString(remote.ID), time.Now()) {
+		m.lock.Unlock()
+		log.WithFields(log.Fields{
+			"process": "peermgr",
+			"address": cc.RemoteAddr().String(),
+		}).Debugln("banned peer refused")
+		_ = cc.Close()
+		return
+	}
+
+	if p, ok := m.peers[cc]; ok {
+		p.remote = remote
+		// the connection is healthy, the next disconnection is not the
+		// fault of the address
+		if c, ok := m.candidates[p.addr]; ok && !p.inbound {
+			c.attempts = 0
+			if m.book != nil {
+				var id []byte
+				if remote != nil {
+					id = remote.ID
+				}
+
+				m.book.Good(p.addr, id)
+			}
+		}
+	}
+	m.lock.Unlock()
+}
+
+// track a new connection. Must be called with the lock held.
+func (m *Manager) track(conn net.Conn, addr string, inbound bool) *countingConn {
+	cc := &countingConn{Conn: conn}
+	cc.onClose = func() { m.onClose(cc) }
+	cc.onMisbehave = func(score uint32, reason string) { m.misbehaving(cc, score, reason) }
+	m.peers[cc] = &connectedPeer{
+		addr:    addr,
+		inbound: inbound,
+		since:   time.Now(),
+		conn:    cc,
+	}
+
+	return cc
+}
+
+func (m *Manager) onClose(cc *countingConn) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	p, ok := m.peers[cc]
+	if !ok {
+		return
+	}
+
+	delete(m.peers, cc)
+	if !p.inbound {
+		m.failed(p.addr)
+	}
+
+	log.WithFields(log.Fields{
+		"process": "peermgr",
+		"address": p.addr,
+	}).Debugln("peer disconnected")
+}
+
+// failed schedules the next dial of a candidate. Must be called with the lock held.
+func (m *Manager) failed(addr string) {
+	c, ok := m.candidates[addr]
+	if !ok {
+		return
+	}
+
+	// the address book keeps its own record of the failures, and will
+	// provide other addresses in the meantime
+	if c.fromBook {
+		delete(m.candidates, addr)
+		return
+	}
+
+	c.pending = false
+	c.connected = false
+	c.next = time.Now().Add(backoff(c.attempts))
+	c.attempts++
+}
+
+// backoff returns the delay before the next dial, doubling on each attempt
+func backoff(attempts uint) time.Duration {
+	if attempts > 16 {
+		return maxBackoff
+	}
+
+	d := minBackoff << attempts
+	if d > maxBackoff {
+		return maxBackoff
+	}
+
+	return d
+}
+
+// allowIP checks the per-IP limit. Must be called with the lock held.
+func (m *Manager) allowIP(conn net.Conn) bool {
+	ip := hostOf(conn.RemoteAddr().String())
+	count := 0
+	for cc := range m.peers {
+		if hostOf(cc.RemoteAddr().String()) == ip {
+			count++
+		}
+	}
+
+	return count < m.cfg.MaxPerIP
+}
+
+func (m *Manager) countInbound() int {
+	count := 0
+	for _, p := range m.peers {
+		if p.inbound {
+			count++
+		}
+	}
+
+	return count
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
+
+// Peers returns the state of all the connected peers
+func (m *Manager) Peers() []Stats {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stats := make([]Stats, 0, len(m.peers))
+	for _, p := range m.peers {
+		stats = append(stats, p.stats())
+	}
+
+	return stats
+}
+
+// Close stops dialing new peers, and disconnects all the connected ones.
+// Closing a connection terminates both the peer Reader and Writer.
+func (m *Manager) Close() {
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return
+	}
+
+	m.closed = true
+	close(m.quitChan)
+	conns := make([]*countingConn, 0, len(m.peers))
+	for cc := range m.peers {
+		conns = append(conns, cc)
+	}
+	m.lock.Unlock()
+
+	for _, cc := range conns {
+		_ = cc.Close()
+	}
+}
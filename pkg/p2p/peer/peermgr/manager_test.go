@@ -0,0 +1,240 @@
+package peermgr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+var okHandler = func(conn net.Conn, inbound bool) (*peer.Remote, error) {
+	return &peer.Remote{
+		Version: &peer.VersionMessage{Version: protocol.NodeVer, Services: protocol.FullNode},
+		ID:      make([]byte, 32),
+	}, nil
+}
+
+// Ensure that inbound connections exceeding the per-IP limit are refused, and
+// that disconnected peers are released.
+func TestInboundLimits(t *testing.T) {
+	m := New(Config{MaxInbound: 10, MaxPerIP: 2}, nil, okHandler)
+
+	var remotes []net.Conn
+	for i := 0; i < 3; i++ {
+		local, remote := net.Pipe()
+		remotes = append(remotes, remote)
+		m.Accept(local)
+	}
+
+	peers := m.Peers()
+	assert.Equal(t, 2, len(peers))
+	assert.True(t, peers[0].Inbound)
+	assert.Equal(t, protocol.NodeVer.String(), peers[0].Version)
+
+	// the refused connection is closed
+	_, err := remotes[2].Write([]byte{0})
+	assert.Error(t, err)
+
+	m.Close()
+	assert.Equal(t, 0, len(m.Peers()))
+}
+
+// Ensure that an address is redialed with a backoff after a failure.
+func TestReconnect(t *testing.T) {
+	defer restoreTimings(minBackoff, maintainInterval)
+	minBackoff = 10 * time.Millisecond
+	maintainInterval = 10 * time.Millisecond
+
+	var lock sync.Mutex
+	attempts := 0
+	dial := func(addr string) (net.Conn, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+
+		local, _ := net.Pipe()
+		return local, nil
+	}
+
+	m := New(Config{TargetOutbound: 1}, dial, okHandler)
+	m.AddAddresses("127.0.0.1:7000")
+	m.Start()
+	defer m.Close()
+
+	time.Sleep(500 * time.Millisecond)
+
+	peers := m.Peers()
+	assert.Equal(t, 1, len(peers))
+	assert.False(t, peers[0].Inbound)
+	assert.Equal(t, "127.0.0.1:7000", peers[0].Address)
+
+	lock.Lock()
+	assert.Equal(t, 3, attempts)
+	lock.Unlock()
+}
+
+// Ensure that the outbound slots are not taken by peers not serving blocks.
+func TestOutboundServesBlocks(t *testing.T) {
+	defer restoreTimings(minBackoff, maintainInterval)
+	minBackoff = time.Hour
+	maintainInterval = 10 * time.Millisecond
+
+	dial := func(addr string) (net.Conn, error) {
+		local, _ := net.Pipe()
+		return local, nil
+	}
+
+	lightHandler := func(conn net.Conn, inbound bool) (*peer.Remote, error) {
+		return &peer.Remote{
+			Version: &peer.VersionMessage{Version: protocol.NodeVer, Services: protocol.LightNode},
+			ID:      make([]byte, 32),
+		}, nil
+	}
+
+	m := New(Config{TargetOutbound: 1}, dial, lightHandler)
+	m.AddAddresses("127.0.0.1:7000")
+	m.Start()
+	defer m.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, len(m.Peers()))
+
+	// light nodes are still welcome as inbound peers
+	local, _ := net.Pipe()
+	m.Accept(local)
+	assert.Equal(t, 1, len(m.Peers()))
+}
+
+// fakeBook is an address book of nodes with known identities
+type fakeBook struct {
+	ids map[string][]byte
+}
+
+func (b *fakeBook) Select(n int, exclude map[string]bool) []string { return nil }
+func (b *fakeBook) Attempt(addr string)                            {}
+func (b *fakeBook) Good(addr string, id []byte)                    {}
+
+func (b *fakeBook) SelectNodes(n int, exclude map[string]bool, accept func(id []byte) bool) []string {
+	selected := make([]string, 0, n)
+	for addr, id := range b.ids {
+		if len(selected) < n && !exclude[addr] && accept(id) {
+			selected = append(selected, addr)
+		}
+	}
+
+	return selected
+}
+
+// wantedNode is an overlay wanting a single node
+type wantedNode []byte
+
+func (w wantedNode) Wants(id []byte) bool {
+	return bytes.Equal(w, id)
+}
+
+// Ensure that the nodes wanted by the overlay are dialed on top of the
+// outbound target.
+func TestOverlayOutbound(t *testing.T) {
+	defer restoreTimings(minBackoff, maintainInterval)
+	maintainInterval = 10 * time.Millisecond
+
+	dial := func(addr string) (net.Conn, error) {
+		local, _ := net.Pipe()
+		return local, nil
+	}
+
+	m := New(Config{TargetOutbound: 1}, dial, okHandler)
+	m.UseAddressBook(&fakeBook{map[string][]byte{
+		"10.0.0.1:7000": {1},
+		"10.0.0.2:7000": {2},
+	}})
+	m.UseOverlay(wantedNode{2})
+	m.Start()
+	defer m.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	peers := m.Peers()
+	assert.Equal(t, 1, len(peers))
+	assert.Equal(t, "10.0.0.2:7000", peers[0].Address)
+}
+
+// ipConn is a pipe end reporting a TCP remote address
+type ipConn struct {
+	net.Conn
+	ip string
+}
+
+func (c *ipConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(c.ip), Port: 7000}
+}
+
+// Ensure that a peer reaching the ban threshold is disconnected and banned,
+// and that the ban survives a restart.
+func TestBanScore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peermgr")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{BanThreshold: 50, BanList: filepath.Join(dir, "banlist.json")}
+	m := New(cfg, nil, okHandler)
+
+	local, remote := net.Pipe()
+	m.Accept(&ipConn{local, "10.0.0.1"})
+
+	var cc *countingConn
+	m.lock.Lock()
+	for c := range m.peers {
+		cc = c
+	}
+	m.lock.Unlock()
+
+	cc.Misbehaving(20, "malformed frame")
+	assert.Equal(t, uint32(20), m.Peers()[0].BanScore)
+
+	cc.Misbehaving(30, "malformed frame")
+	assert.Equal(t, 0, len(m.Peers()))
+	_, err = remote.Write([]byte{0})
+	assert.Error(t, err)
+
+	// both the IP and the ID of the peer are banned
+	id := hex.EncodeToString(make([]byte, 32))
+	bans := m.Bans()
+	assert.Equal(t, 2, len(bans))
+	assert.Equal(t, "10.0.0.1", bans[0].Target)
+	assert.Equal(t, id, bans[1].Target)
+
+	// connections from a banned IP are refused
+	local, _ = net.Pipe()
+	m.Accept(&ipConn{local, "10.0.0.1"})
+	assert.Equal(t, 0, len(m.Peers()))
+
+	// so are the connections from a banned ID, whatever their IP
+	local, _ = net.Pipe()
+	m.Accept(&ipConn{local, "10.0.0.2"})
+	assert.Equal(t, 0, len(m.Peers()))
+	m.Close()
+
+	restarted := New(cfg, nil, okHandler)
+	assert.Equal(t, 2, len(restarted.Bans()))
+	assert.True(t, restarted.Unban("10.0.0.1"))
+	assert.Equal(t, 1, len(restarted.Bans()))
+}
+
+// restoreTimings resets the timings overridden by a test
+func restoreTimings(backoff, interval time.Duration) {
+	minBackoff = backoff
+	maintainInterval = interval
+}
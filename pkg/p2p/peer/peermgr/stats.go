@@ -0,0 +1,162 @@
+package peermgr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+// Stats holds the state of a connected peer
+type Stats struct {
+	Address string `json:"address"`
+	// ID is the hex encoded identity key of the peer, empty until the
+	// handshake is completed
+	ID      string `json:"id"`
+	Inbound bool   `json:"inbound"`
+	// Version is empty until the handshake is completed
+	Version  string `json:"version"`
+	Services uint64 `json:"services"`
+	// BestHeight is the chain tip advertised by the peer when connecting
+	BestHeight uint64 `json:"bestHeight"`
+	// Features are the optional parts of the protocol supported by the peer
+	Features       uint64 `json:"features"`
+	ConnectedSince int64  `json:"connectedSince"`
+	BytesIn        uint64 `json:"bytesIn"`
+	BytesOut       uint64 `json:"bytesOut"`
+	// PingTime is the last round trip time in microseconds, zero until the
+	// first pong is received
+	PingTime uint64 `json:"pingTime"`
+	// BanScore is the accumulated score of the protocol violations
+	BanScore uint32 `json:"banScore"`
+}
+
+// id returns the hex encoded identity key of the peer, or an empty string
+// before the handshake is completed
+func (p *connectedPeer) id() string {
+	if p.remote == nil {
+		return ""
+	}
+
+	return hex.EncodeToString(p.remote.ID)
+}
+
+func (p *connectedPeer) stats() Stats {
+	s := Stats{
+		Address:        p.addr,
+		Inbound:        p.inbound,
+		ConnectedSince: p.since.Unix(),
+		BanScore:       p.score,
+	}
+
+	if p.remote != nil {
+		s.ID = p.id()
+		if p.remote.Version != nil {
+			s.Version = p.remote.Version.Version.String()
+			s.Services = uint64(p.remote.Version.Services)
+			s.BestHeight = p.remote.Version.BestHeight
+			s.Features = uint64(p.remote.Version.Features)
+		}
+	}
+
+	s.BytesIn, s.BytesOut = p.conn.stats()
+	s.PingTime = uint64(p.conn.latency() / time.Microsecond)
+	return s
+}
+
+func (m *Manager) onGetPeerInfo(r wire.Req) {
+	peers := m.Peers()
+
+	w := new(bytes.Buffer)
+	if err := encoding.WriteVarInt(w, uint64(len(peers))); err != nil {
+		r.ErrChan <- err
+		return
+	}
+
+	for _, s := range peers {
+		if err := s.Encode(w); err != nil {
+			r.ErrChan <- err
+			return
+		}
+	}
+
+	r.RespChan <- *w
+}
+
+// Encode a Stats struct and write it to w.
+func (s Stats) Encode(w io.Writer) error {
+	if err := encoding.WriteString(w, s.Address); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteString(w, s.ID); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteBool(w, s.Inbound); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteString(w, s.Version); err != nil {
+		return err
+	}
+
+	for _, v := range []uint64{s.Services, s.BestHeight, s.Features, uint64(s.ConnectedSince), s.BytesIn, s.BytesOut, s.PingTime} {
+		if err := encoding.WriteUint64(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	return encoding.WriteUint32(w, binary.LittleEndian, s.BanScore)
+}
+
+// Decode a Stats struct from r.
+func (s *Stats) Decode(r io.Reader) error {
+	if err := encoding.ReadString(r, &s.Address); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadString(r, &s.ID); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadBool(r, &s.Inbound); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadString(r, &s.Version); err != nil {
+		return err
+	}
+
+	var since uint64
+	for _, v := range []*uint64{&s.Services, &s.BestHeight, &s.Features, &since, &s.BytesIn, &s.BytesOut, &s.PingTime} {
+		if err := encoding.ReadUint64(r, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	s.ConnectedSince = int64(since)
+	return encoding.ReadUint32(r, binary.LittleEndian, &s.BanScore)
+}
+
+// DecodeStats decodes a list of Stats, as returned by the wire.GetPeerInfo
+// method.
+func DecodeStats(r *bytes.Buffer) ([]Stats, error) {
+	lStats, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]Stats, lStats)
+	for i := range stats {
+		if err := stats[i].Decode(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
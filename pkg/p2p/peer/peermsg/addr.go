@@ -0,0 +1,115 @@
+package peermsg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+// MaxAddrs is the maximum amount of addresses in an Addr message
+const MaxAddrs = 1000
+
+// NetAddress is the network address of a node, along with the last time it
+// was seen by the sender.
+type NetAddress struct {
+	IP       net.IP
+	Port     uint16
+	LastSeen int64
+}
+
+// NewNetAddress parses a "host:port" string into a NetAddress. The host must be
+// an IP address.
+func NewNetAddress(addr string, lastSeen int64) (*NetAddress, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip address %s", host)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NetAddress{IP: ip, Port: uint16(port), LastSeen: lastSeen}, nil
+}
+
+// String returns the address in the "host:port" format.
+func (n NetAddress) String() string {
+	return net.JoinHostPort(n.IP.String(), strconv.Itoa(int(n.Port)))
+}
+
+// Addr defines an addr message on the Dusk wire protocol. It is used to share
+// the addresses of known nodes, usually in response to a GetAddr message.
+type Addr struct {
+	Addresses []NetAddress
+}
+
+// Encode an Addr struct and write it to w.
+func (a *Addr) Encode(w io.Writer) error {
+	if len(a.Addresses) > MaxAddrs {
+		return errors.New("too many addresses")
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(a.Addresses))); err != nil {
+		return err
+	}
+
+	for _, addr := range a.Addresses {
+		if _, err := w.Write(addr.IP.To16()); err != nil {
+			return err
+		}
+
+		if err := encoding.WriteUint16(w, binary.LittleEndian, addr.Port); err != nil {
+			return err
+		}
+
+		if err := encoding.WriteUint64(w, binary.LittleEndian, uint64(addr.LastSeen)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode an Addr struct from r into a.
+func (a *Addr) Decode(r io.Reader) error {
+	lAddrs, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lAddrs > MaxAddrs {
+		return errors.New("too many addresses")
+	}
+
+	a.Addresses = make([]NetAddress, lAddrs)
+	for i := range a.Addresses {
+		ip := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return err
+		}
+		a.Addresses[i].IP = net.IP(ip)
+
+		if err := encoding.ReadUint16(r, binary.LittleEndian, &a.Addresses[i].Port); err != nil {
+			return err
+		}
+
+		var lastSeen uint64
+		if err := encoding.ReadUint64(r, binary.LittleEndian, &lastSeen); err != nil {
+			return err
+		}
+		a.Addresses[i].LastSeen = int64(lastSeen)
+	}
+
+	return nil
+}
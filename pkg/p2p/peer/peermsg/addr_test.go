@@ -0,0 +1,35 @@
+package peermsg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeAddr(t *testing.T) {
+	addr := &peermsg.Addr{}
+	for _, a := range []string{"127.0.0.1:7000", "[2001:db8::1]:7001"} {
+		netAddr, err := peermsg.NewNetAddress(a, 1500000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr.Addresses = append(addr.Addresses, *netAddr)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := addr.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	addr2 := &peermsg.Addr{}
+	if err := addr2.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(addr2.Addresses))
+	assert.Equal(t, "127.0.0.1:7000", addr2.Addresses[0].String())
+	assert.Equal(t, "[2001:db8::1]:7001", addr2.Addresses[1].String())
+	assert.Equal(t, int64(1500000000), addr2.Addresses[1].LastSeen)
+}
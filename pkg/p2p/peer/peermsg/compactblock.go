@@ -0,0 +1,263 @@
+package peermsg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/crypto/hash"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+// ShortIDSize is the size in bytes of the short tx IDs of a compact block
+const ShortIDSize = 6
+
+// maxCompactTxs bounds the amount of txs a compact block can list
+var maxCompactTxs = uint64(config.MaxBlockSize / ShortIDSize)
+
+var errTooManyTxs = errors.New("too many txs")
+
+// PrefilledTx is a tx sent in full in a compact block, with its index in the
+// block
+type PrefilledTx struct {
+	Index uint32
+	Tx    transactions.Transaction
+}
+
+// CompactBlock defines a cmpctblock message on the Dusk wire protocol. It carries
+// the header of a block, and the short IDs of its txs, so that the receiver can
+// rebuild the block from its mempool. The txs the receiver can not have, like the
+// coinbase, are sent in full.
+type CompactBlock struct {
+	Header    *block.Header
+	ShortIDs  [][]byte
+	Prefilled []PrefilledTx
+}
+
+// NewCompactBlock returns the compact representation of blk.
+func NewCompactBlock(blk *block.Block) (*CompactBlock, error) {
+	c := &CompactBlock{Header: blk.Header}
+	for i, tx := range blk.Txs {
+		if tx.Type() == transactions.CoinbaseType {
+			c.Prefilled = append(c.Prefilled, PrefilledTx{Index: uint32(i), Tx: tx})
+			continue
+		}
+
+		txID, err := tx.CalculateHash()
+		if err != nil {
+			return nil, err
+		}
+
+		shortID, err := ShortID(blk.Header.Hash, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		c.ShortIDs = append(c.ShortIDs, shortID)
+	}
+
+	return c, nil
+}
+
+// ShortID returns the short ID of a tx in the block with the given hash. The
+// block hash salts the IDs, so that the collisions differ from block to block.
+func ShortID(blockHash, txID []byte) ([]byte, error) {
+	h, err := hash.Xxhash(append(append(make([]byte, 0, len(blockHash)+len(txID)), blockHash...), txID...))
+	if err != nil {
+		return nil, err
+	}
+
+	return h[:ShortIDSize], nil
+}
+
+// TxCount returns the amount of txs in the block
+func (c *CompactBlock) TxCount() int {
+	return len(c.ShortIDs) + len(c.Prefilled)
+}
+
+// Encode a CompactBlock struct and write it to w.
+func (c *CompactBlock) Encode(w io.Writer) error {
+	if err := c.Header.Encode(w); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(c.ShortIDs))); err != nil {
+		return err
+	}
+
+	for _, shortID := range c.ShortIDs {
+		if _, err := w.Write(shortID); err != nil {
+			return err
+		}
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(c.Prefilled))); err != nil {
+		return err
+	}
+
+	for _, p := range c.Prefilled {
+		if err := encoding.WriteUint32(w, binary.LittleEndian, p.Index); err != nil {
+			return err
+		}
+	}
+
+	// the txs come last, as they are decoded from the rest of the message
+	for _, p := range c.Prefilled {
+		if err := p.Tx.Encode(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode a CompactBlock struct from r into c.
+func (c *CompactBlock) Decode(r io.Reader) error {
+	c.Header = &block.Header{}
+	if err := c.Header.Decode(r); err != nil {
+		return err
+	}
+
+	lShortIDs, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lShortIDs > maxCompactTxs {
+		return errTooManyTxs
+	}
+
+	c.ShortIDs = make([][]byte, lShortIDs)
+	for i := range c.ShortIDs {
+		c.ShortIDs[i] = make([]byte, ShortIDSize)
+		if _, err := io.ReadFull(r, c.ShortIDs[i]); err != nil {
+			return err
+		}
+	}
+
+	lPrefilled, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lPrefilled > maxCompactTxs-lShortIDs {
+		return errTooManyTxs
+	}
+
+	c.Prefilled = make([]PrefilledTx, lPrefilled)
+	for i := range c.Prefilled {
+		if err := encoding.ReadUint32(r, binary.LittleEndian, &c.Prefilled[i].Index); err != nil {
+			return err
+		}
+	}
+
+	txs, err := transactions.FromReader(r, lPrefilled)
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range txs {
+		c.Prefilled[i].Tx = tx
+	}
+
+	return nil
+}
+
+// GetBlockTxn defines a getblocktxn message on the Dusk wire protocol. It is used
+// to request the txs of a compact block which are missing from the mempool.
+type GetBlockTxn struct {
+	BlockHash []byte
+	Indexes   []uint32
+}
+
+// Encode a GetBlockTxn struct and write it to w.
+func (g *GetBlockTxn) Encode(w io.Writer) error {
+	if err := encoding.Write256(w, g.BlockHash); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(g.Indexes))); err != nil {
+		return err
+	}
+
+	for _, index := range g.Indexes {
+		if err := encoding.WriteUint32(w, binary.LittleEndian, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode a GetBlockTxn struct from r into g.
+func (g *GetBlockTxn) Decode(r io.Reader) error {
+	if err := encoding.Read256(r, &g.BlockHash); err != nil {
+		return err
+	}
+
+	lIndexes, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lIndexes > maxCompactTxs {
+		return errTooManyTxs
+	}
+
+	g.Indexes = make([]uint32, lIndexes)
+	for i := range g.Indexes {
+		if err := encoding.ReadUint32(r, binary.LittleEndian, &g.Indexes[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlockTxn defines a blocktxn message on the Dusk wire protocol. It carries the
+// txs of a block requested with a GetBlockTxn, in the requested order.
+type BlockTxn struct {
+	BlockHash []byte
+	Txs       []transactions.Transaction
+}
+
+// Encode a BlockTxn struct and write it to w.
+func (b *BlockTxn) Encode(w io.Writer) error {
+	if err := encoding.Write256(w, b.BlockHash); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(b.Txs))); err != nil {
+		return err
+	}
+
+	for _, tx := range b.Txs {
+		if err := tx.Encode(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode a BlockTxn struct from r into b.
+func (b *BlockTxn) Decode(r io.Reader) error {
+	if err := encoding.Read256(r, &b.BlockHash); err != nil {
+		return err
+	}
+
+	lTxs, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lTxs > maxCompactTxs {
+		return errTooManyTxs
+	}
+
+	b.Txs, err = transactions.FromReader(r, lTxs)
+	return err
+}
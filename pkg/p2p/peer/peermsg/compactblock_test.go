@@ -0,0 +1,31 @@
+package peermsg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeCompactBlock(t *testing.T) {
+	blk := helper.RandomBlock(t, 5, 2)
+	c, err := peermsg.NewCompactBlock(blk)
+	assert.NoError(t, err)
+
+	// the coinbase is sent in full
+	assert.Equal(t, 1, len(c.Prefilled))
+	assert.Equal(t, uint32(0), c.Prefilled[0].Index)
+	assert.Equal(t, len(blk.Txs), c.TxCount())
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, c.Encode(buf))
+
+	c2 := &peermsg.CompactBlock{}
+	assert.NoError(t, c2.Decode(buf))
+	assert.Equal(t, c.Header, c2.Header)
+	assert.Equal(t, c.ShortIDs, c2.ShortIDs)
+	assert.Equal(t, c.Prefilled[0].Index, c2.Prefilled[0].Index)
+	assert.True(t, c.Prefilled[0].Tx.Equals(c2.Prefilled[0].Tx))
+}
@@ -1,11 +1,16 @@
 package peermsg
 
 import (
+	"errors"
 	"io"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 )
 
+// MaxLocators is the maximum amount of locators in a GetBlocks message, enough
+// to describe any chain with an exponentially sparser locator
+const MaxLocators = 101
+
 // GetBlocks defines a getblocks message on the Dusk wire protocol. It is used to
 // request blocks from another peer.
 type GetBlocks struct {
@@ -34,6 +39,10 @@ func (g *GetBlocks) Decode(r io.Reader) error {
 		return err
 	}
 
+	if lenLocators > MaxLocators {
+		return errors.New("too many locators")
+	}
+
 	g.Locators = make([][]byte, lenLocators)
 	for i := uint64(0); i < lenLocators; i++ {
 		if err := encoding.Read256(r, &g.Locators[i]); err != nil {
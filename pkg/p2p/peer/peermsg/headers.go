@@ -0,0 +1,71 @@
+package peermsg
+
+import (
+	"errors"
+	"io"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+// MaxHeaders is the maximum amount of headers in a Headers message
+const MaxHeaders = 500
+
+// GetHeaders defines a getheaders message on the Dusk wire protocol. It is used
+// to request the headers of the blocks following the locators.
+type GetHeaders struct {
+	Locators [][]byte
+}
+
+// Encode a GetHeaders struct and write it to w.
+func (g *GetHeaders) Encode(w io.Writer) error {
+	return (*GetBlocks)(g).Encode(w)
+}
+
+// Decode a GetHeaders struct from r into g.
+func (g *GetHeaders) Decode(r io.Reader) error {
+	return (*GetBlocks)(g).Decode(r)
+}
+
+// Headers defines a headers message on the Dusk wire protocol. It carries a
+// chain of block headers, in height order.
+type Headers struct {
+	Headers []*block.Header
+}
+
+// Encode a Headers struct and write it to w.
+func (h *Headers) Encode(w io.Writer) error {
+	if err := encoding.WriteVarInt(w, uint64(len(h.Headers))); err != nil {
+		return err
+	}
+
+	for _, header := range h.Headers {
+		if err := header.Encode(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode a Headers struct from r into h.
+func (h *Headers) Decode(r io.Reader) error {
+	lHeaders, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lHeaders > MaxHeaders {
+		return errors.New("too many headers")
+	}
+
+	h.Headers = make([]*block.Header, lHeaders)
+	for i := range h.Headers {
+		h.Headers[i] = &block.Header{}
+		if err := h.Headers[i].Decode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
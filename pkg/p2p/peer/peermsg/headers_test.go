@@ -0,0 +1,29 @@
+package peermsg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeHeaders(t *testing.T) {
+	headers := &peermsg.Headers{}
+	for i := uint64(0); i < 5; i++ {
+		headers.Headers = append(headers.Headers, helper.RandomBlock(t, i, 1).Header)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := headers.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	headers2 := &peermsg.Headers{}
+	if err := headers2.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, headers, headers2)
+}
@@ -12,10 +12,13 @@ type InvType uint8
 var (
 	InvTypeMempoolTx InvType = 0
 	InvTypeBlock     InvType = 1
+	// InvTypeCompactBlock requests a block as a CompactBlock, in a GetData
+	InvTypeCompactBlock InvType = 2
 
-	supportedInvTypes = [2]InvType{
+	supportedInvTypes = [3]InvType{
 		InvTypeMempoolTx,
 		InvTypeBlock,
+		InvTypeCompactBlock,
 	}
 )
 
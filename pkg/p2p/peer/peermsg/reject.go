@@ -0,0 +1,120 @@
+package peermsg
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+// RejectCode is a machine-readable identifier of the reason why an item was
+// rejected by a node.
+type RejectCode uint8
+
+const (
+	// RejectMalformed is used when an item could not be decoded
+	RejectMalformed RejectCode = 0x01
+	// RejectInvalid is used when an item breaks any of the consensus rules
+	RejectInvalid RejectCode = 0x10
+	// RejectDoubleSpend is used when a tx spends an already spent input
+	RejectDoubleSpend RejectCode = 0x11
+	// RejectFeeTooLow is used when a tx pays less than the minimum fee
+	RejectFeeTooLow RejectCode = 0x12
+	// RejectDuplicate is used when an item is already known to the node
+	RejectDuplicate RejectCode = 0x13
+	// RejectNotAllowed is used when an item should never be relayed (e.g a
+	// coinbase tx outside of a block)
+	RejectNotAllowed RejectCode = 0x14
+	// RejectOrphan is used when a block does not follow the tip of the node,
+	// which an honest peer on a competing tip may relay
+	RejectOrphan RejectCode = 0x15
+	// RejectInternal is used when the node failed to process an item,
+	// through no fault of the peer
+	RejectInternal RejectCode = 0x16
+)
+
+var rejectCodeNames = map[RejectCode]string{
+	RejectMalformed:   "malformed",
+	RejectInvalid:     "invalid",
+	RejectDoubleSpend: "double-spend",
+	RejectFeeTooLow:   "fee-too-low",
+	RejectDuplicate:   "already-known",
+	RejectNotAllowed:  "not-allowed",
+	RejectOrphan:      "orphan",
+	RejectInternal:    "internal-error",
+}
+
+func (c RejectCode) String() string {
+	if name, ok := rejectCodeNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(%d)", uint8(c))
+}
+
+// Reject defines a reject message on the Dusk wire protocol. It is sent back to
+// a peer which relayed an item (tx or block) that was refused by this node.
+// A Reject also satisfies the error interface, so that it can be returned as-is
+// by the components which validate the items.
+type Reject struct {
+	// Message is the topic of the rejected item
+	Message topics.Topic
+	Code    RejectCode
+	Reason  string
+	// Hash of the rejected item. Can be empty, if the item could not be decoded
+	Hash []byte
+}
+
+// NewReject returns a Reject for the item identified by hash.
+func NewReject(message topics.Topic, code RejectCode, reason string, hash []byte) *Reject {
+	return &Reject{
+		Message: message,
+		Code:    code,
+		Reason:  reason,
+		Hash:    hash,
+	}
+}
+
+// Error implements the error interface.
+func (r *Reject) Error() string {
+	return fmt.Sprintf("%s rejected (%s): %s", r.Message, r.Code, r.Reason)
+}
+
+// Encode a Reject struct and write it to w.
+func (r *Reject) Encode(w io.Writer) error {
+	if err := topics.Write(w, r.Message); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint8(w, uint8(r.Code)); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteString(w, r.Reason); err != nil {
+		return err
+	}
+
+	return encoding.WriteVarBytes(w, r.Hash)
+}
+
+// Decode a Reject struct from r.
+func (r *Reject) Decode(rd io.Reader) error {
+	message, err := topics.Extract(rd)
+	if err != nil {
+		return err
+	}
+	r.Message = message
+
+	var code uint8
+	if err := encoding.ReadUint8(rd, &code); err != nil {
+		return err
+	}
+	r.Code = RejectCode(code)
+
+	if err := encoding.ReadString(rd, &r.Reason); err != nil {
+		return err
+	}
+
+	return encoding.ReadVarBytes(rd, &r.Hash)
+}
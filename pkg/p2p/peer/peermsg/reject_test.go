@@ -0,0 +1,27 @@
+package peermsg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/crypto"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeReject(t *testing.T) {
+	hash, _ := crypto.RandEntropy(32)
+	reject := peermsg.NewReject(topics.Tx, peermsg.RejectFeeTooLow, "fee too low", hash)
+	buf := new(bytes.Buffer)
+	if err := reject.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	reject2 := &peermsg.Reject{}
+	if err := reject2.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, reject, reject2)
+}
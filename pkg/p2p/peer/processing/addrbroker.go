@@ -0,0 +1,70 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+// maxAddrsPerResponse is the amount of addresses sent in response to a GetAddr
+var maxAddrsPerResponse = uint16(250)
+
+// AddrBroker is a processing unit which exchanges node addresses with a peer.
+// It maintains a connection to the outgoing message queue of an individual peer.
+type AddrBroker struct {
+	rpcBus       *wire.RPCBus
+	responseChan chan<- *bytes.Buffer
+
+	// a peer is served a single Addr message per connection, to prevent it
+	// from mapping our whole address book
+	served bool
+}
+
+// NewAddrBroker returns an initialized AddrBroker.
+func NewAddrBroker(rpcBus *wire.RPCBus, responseChan chan<- *bytes.Buffer) *AddrBroker {
+	return &AddrBroker{
+		rpcBus:       rpcBus,
+		responseChan: responseChan,
+	}
+}
+
+// RequestAddresses sends a GetAddr message to the peer
+func (a *AddrBroker) RequestAddresses() error {
+	msg, err := wire.AddTopic(new(bytes.Buffer), topics.GetAddr)
+	if err != nil {
+		return err
+	}
+
+	a.responseChan <- msg
+	return nil
+}
+
+// SendAddresses responds to a GetAddr message with a selection of the addresses
+// in the address book.
+func (a *AddrBroker) SendAddresses() error {
+	if a.served {
+		return nil
+	}
+	a.served = true
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint16(buf, binary.LittleEndian, maxAddrsPerResponse); err != nil {
+		return err
+	}
+
+	r, err := a.rpcBus.Call(wire.GetAddresses, wire.NewRequest(*buf, 2))
+	if err != nil {
+		return err
+	}
+
+	msg, err := wire.AddTopic(&r, topics.Addr)
+	if err != nil {
+		return err
+	}
+
+	a.responseChan <- msg
+	return nil
+}
@@ -2,6 +2,7 @@ package processing
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
@@ -9,7 +10,9 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 )
 
-// BlockHashBroker is a processing unit which handles GetBlocks messages.
+var errNoCommonBlock = errors.New("no block in common with the locator")
+
+// BlockHashBroker is a processing unit which handles GetBlocks and GetHeaders messages.
 // It has a database connection, and a channel pointing to the outgoing message queue
 // of the requesting peer.
 type BlockHashBroker struct {
@@ -78,17 +81,82 @@ func (b *BlockHashBroker) AdvertiseMissingBlocks(m *bytes.Buffer) error {
 	return nil
 }
 
-// Determine a peer's height from his locator hash.
+// SendHeaders takes a GetHeaders wire message, and sends the headers of up to
+// peermsg.MaxHeaders blocks which follow the provided locator to the requesting
+// peer.
+func (b *BlockHashBroker) SendHeaders(m *bytes.Buffer) error {
+	msg := &peermsg.GetHeaders{}
+	if err := msg.Decode(m); err != nil {
+		return err
+	}
+
+	height, err := b.fetchLocatorHeight((*peermsg.GetBlocks)(msg))
+	if err != nil {
+		return err
+	}
+
+	headers := &peermsg.Headers{}
+	err = b.db.View(func(t database.Transaction) error {
+		for len(headers.Headers) < peermsg.MaxHeaders {
+			height++
+			hash, err := t.FetchBlockHashByHeight(height)
+			if err != nil {
+				// we passed the tip of the chain
+				return nil
+			}
+
+			header, err := t.FetchBlockHeader(hash)
+			if err != nil {
+				return err
+			}
+
+			headers.Headers = append(headers.Headers, header)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// an empty Headers tells the peer that it has our tip already
+	buf := new(bytes.Buffer)
+	if err := headers.Encode(buf); err != nil {
+		return err
+	}
+
+	msgBuf, err := wire.AddTopic(buf, topics.Headers)
+	if err != nil {
+		return err
+	}
+
+	b.responseChan <- msgBuf
+	return nil
+}
+
+// Determine the height of the latest block we have in common with the peer, from
+// his locator. The locators are ordered from the peer's tip downwards, so the
+// first one which is part of our chain is the latest common block.
 func (b *BlockHashBroker) fetchLocatorHeight(msg *peermsg.GetBlocks) (uint64, error) {
 	var height uint64
 	err := b.db.View(func(t database.Transaction) error {
-		header, err := t.FetchBlockHeader(msg.Locators[0])
-		if err != nil {
-			return err
+		for _, locator := range msg.Locators {
+			header, err := t.FetchBlockHeader(locator)
+			if err != nil {
+				continue
+			}
+
+			// the block may be stored, without being part of our chain
+			hash, err := t.FetchBlockHashByHeight(header.Height)
+			if err != nil || !bytes.Equal(hash, locator) {
+				continue
+			}
+
+			height = header.Height
+			return nil
 		}
 
-		height = header.Height
-		return nil
+		return errNoCommonBlock
 	})
 
 	return height, err
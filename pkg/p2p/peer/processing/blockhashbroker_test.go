@@ -59,6 +59,97 @@ func TestAdvertiseBlocks(t *testing.T) {
 	}
 }
 
+// The blocks following the latest block in common with the locator should be
+// advertised, when the tip of the peer is not part of our chain.
+func TestAdvertiseBlocksFromCommonBlock(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+	defer db.Close()
+
+	hashes, blocks := generateBlocks(t, 5)
+	if err := storeBlocks(db, blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	responseChan := make(chan *bytes.Buffer, 100)
+	blockHashBroker := processing.NewBlockHashBroker(db, responseChan)
+
+	// the tip of the peer is unknown to us
+	getBlocks := &peermsg.GetBlocks{Locators: [][]byte{helper.RandomSlice(t, 32), hashes[2], hashes[0]}}
+	buf := new(bytes.Buffer)
+	if err := getBlocks.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := blockHashBroker.AdvertiseMissingBlocks(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	response := <-responseChan
+	if topic := extractTopic(response); topic != topics.Inv {
+		t.Fatalf("unexpected topic %s, expected Inv", topic)
+	}
+
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inv.InvList) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(inv.InvList))
+	}
+
+	for i, item := range inv.InvList {
+		if !bytes.Equal(hashes[i+3], item.Hash) {
+			t.Fatal("received inv vector has mismatched hash")
+		}
+	}
+}
+
+// Test the behaviour of the block hash broker, upon receiving a GetHeaders message.
+func TestSendHeaders(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+	defer db.Close()
+
+	hashes, blocks := generateBlocks(t, 5)
+	if err := storeBlocks(db, blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	responseChan := make(chan *bytes.Buffer, 100)
+	blockHashBroker := processing.NewBlockHashBroker(db, responseChan)
+
+	getHeaders := &peermsg.GetHeaders{Locators: [][]byte{hashes[1]}}
+	buf := new(bytes.Buffer)
+	if err := getHeaders.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := blockHashBroker.SendHeaders(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	response := <-responseChan
+	if topic := extractTopic(response); topic != topics.Headers {
+		t.Fatalf("unexpected topic %s, expected Headers", topic)
+	}
+
+	headers := &peermsg.Headers{}
+	if err := headers.Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	// the headers following the locator are sent, in height order
+	if len(headers.Headers) != 3 {
+		t.Fatalf("expected 3 headers, got %d", len(headers.Headers))
+	}
+
+	for i, header := range headers.Headers {
+		if !bytes.Equal(hashes[i+2], header.Hash) {
+			t.Fatal("received header has mismatched hash")
+		}
+	}
+}
+
 // Generate a set of random blocks, which follow each other up in the chain.
 func generateBlocks(t *testing.T, amount int) ([][]byte, []*block.Block) {
 	var hashes [][]byte
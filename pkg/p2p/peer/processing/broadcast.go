@@ -0,0 +1,75 @@
+package processing
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+// Broadcaster propagates the messages streamed on topics.Gossip to the peers.
+type Broadcaster interface {
+	// AddPeer starts propagating the gossip to a peer, once the handshake is
+	// done. id is the identity key of the peer, conn receives the gossip
+	// frames, and responseChan is the outgoing message queue of the peer.
+	AddPeer(id []byte, features protocol.Features, conn io.WriteCloser, responseChan chan<- *bytes.Buffer)
+	// RemovePeer stops propagating the gossip to a disconnected peer.
+	RemovePeer(responseChan chan<- *bytes.Buffer)
+	// Relayed records that a message was received through the overlay at the
+	// given height, before it is routed. m holds the topic and the payload.
+	Relayed(m []byte, height uint8)
+	// Features returns the protocol features the peers need to know of, to
+	// receive the gossip.
+	Features() protocol.Features
+}
+
+// Flood is a Broadcaster which streams the gossip to every peer. The duplicates
+// are filtered by the receivers.
+type Flood struct {
+	lock       sync.Mutex
+	subscriber wire.EventSubscriber
+	// the stream subscriptions, by outgoing message queue
+	streams map[chan<- *bytes.Buffer]uint32
+}
+
+// NewFlood returns an initialized Flood.
+func NewFlood(subscriber wire.EventSubscriber) *Flood {
+	return &Flood{
+		subscriber: subscriber,
+		streams:    make(map[chan<- *bytes.Buffer]uint32),
+	}
+}
+
+// AddPeer subscribes conn to the gossip stream.
+func (f *Flood) AddPeer(id []byte, features protocol.Features, conn io.WriteCloser, responseChan chan<- *bytes.Buffer) {
+	// Any gossip topics are written into interrupt-driven ringBuffer
+	// Single-consumer pushes messages to the socket
+	streamID := f.subscriber.SubscribeStream(string(topics.Gossip), conn)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.streams[responseChan] = streamID
+}
+
+// RemovePeer unsubscribes the peer from the gossip stream.
+func (f *Flood) RemovePeer(responseChan chan<- *bytes.Buffer) {
+	f.lock.Lock()
+	streamID, ok := f.streams[responseChan]
+	delete(f.streams, responseChan)
+	f.lock.Unlock()
+
+	if ok {
+		f.subscriber.Unsubscribe(string(topics.Gossip), streamID)
+	}
+}
+
+// Relayed is a no-op, as flooding does not depend on where messages come from.
+func (f *Flood) Relayed(m []byte, height uint8) {}
+
+// Features returns no feature, as the gossip is sent as is.
+func (f *Flood) Features() protocol.Features {
+	return 0
+}
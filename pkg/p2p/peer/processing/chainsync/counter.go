@@ -5,7 +5,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 )
 
@@ -13,18 +15,38 @@ var syncTime = 30 * time.Second
 
 // Counter is a simple guarded counter, which can be used to figure out if we are currently
 // syncing with another peer. It is used to orchestrate requests for blocks.
+// It also keeps track of the chain tips of the peers serving blocks, so that
+// we sync from the one furthest ahead.
 type Counter struct {
 	lock            sync.RWMutex
 	blocksRemaining uint64
+	// known chain tips of the peers serving blocks, by outgoing message queue
+	tips map[chan<- *bytes.Buffer]uint64
+	// protocol features advertised by the peers, by outgoing message queue
+	features map[chan<- *bytes.Buffer]protocol.Features
+	// the blocks being downloaded from the peers
+	downloads *scheduler
+	// the blocks received ahead of our tip
+	orphans   *orphanPool
+	publisher wire.EventPublisher
 
 	timer    *time.Timer
 	stopChan chan struct{}
 }
 
-// NewCounter returns an initialized counter. It will decrement each time we accept a new block.
-func NewCounter(subscriber wire.EventSubscriber) *Counter {
-	sc := &Counter{stopChan: make(chan struct{})}
-	subscriber.SubscribeCallback(string(topics.AcceptedBlock), sc.decrement)
+// NewCounter returns an initialized counter. It will decrement each time we accept a new block,
+// and publish the orphans following the accepted block.
+func NewCounter(broker wire.EventBroker) *Counter {
+	sc := &Counter{
+		stopChan:  make(chan struct{}),
+		tips:      make(map[chan<- *bytes.Buffer]uint64),
+		features:  make(map[chan<- *bytes.Buffer]protocol.Features),
+		downloads: newScheduler(),
+		orphans:   newOrphanPool(),
+		publisher: broker,
+	}
+	broker.SubscribeCallback(string(topics.AcceptedBlock), sc.decrement)
+	broker.SubscribeCallback(string(topics.AcceptedBlock), sc.connectOrphans)
 	return sc
 }
 
@@ -47,6 +69,29 @@ func (s *Counter) decrement(b *bytes.Buffer) error {
 	return nil
 }
 
+// connectOrphans publishes the orphans whose parent was accepted
+func (s *Counter) connectOrphans(b *bytes.Buffer) error {
+	header := &block.Header{}
+	if err := header.Decode(bytes.NewReader(b.Bytes())); err != nil {
+		return err
+	}
+
+	children := s.orphans.children(header.Hash)
+	if len(children) == 0 {
+		return nil
+	}
+
+	// the block is accepted while the chain is locked, so the orphans are
+	// published once the callback returns
+	go func() {
+		for _, child := range children {
+			s.publisher.Publish(string(topics.Block), child)
+		}
+	}()
+
+	return nil
+}
+
 func (s *Counter) isSyncing() bool {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -67,6 +112,84 @@ func (s *Counter) startSyncing(heightDiff uint64) {
 	go s.listenForTimer(s.timer)
 }
 
+// setTip records the chain tip of a peer, unless a higher one is known already
+func (s *Counter) setTip(peer chan<- *bytes.Buffer, height uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if tip, ok := s.tips[peer]; !ok || height > tip {
+		s.tips[peer] = height
+	}
+}
+
+// forgetTip forgets a disconnected peer. The blocks it was downloading are
+// handed to the other peers.
+func (s *Counter) forgetTip(peer chan<- *bytes.Buffer) {
+	s.lock.Lock()
+	delete(s.tips, peer)
+	delete(s.features, peer)
+	s.lock.Unlock()
+
+	s.downloads.removePeer(peer)
+	s.downloads.assign(s.knownTips(), time.Now())
+}
+
+// setFeatures records the protocol features advertised by a peer
+func (s *Counter) setFeatures(peer chan<- *bytes.Buffer, features protocol.Features) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.features[peer] = features
+}
+
+// supports returns true if the peer advertised the given protocol features.
+// Peers which did not advertise any are assumed to support none.
+func (s *Counter) supports(peer chan<- *bytes.Buffer, f protocol.Features) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.features[peer].Has(f)
+}
+
+// knownTips returns a copy of the known chain tips
+func (s *Counter) knownTips() map[chan<- *bytes.Buffer]uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	tips := make(map[chan<- *bytes.Buffer]uint64, len(s.tips))
+	for peer, height := range s.tips {
+		tips[peer] = height
+	}
+
+	return tips
+}
+
+// watchDownloads reassigns the stalled downloads, until there is nothing left
+// to download
+func (s *Counter) watchDownloads() {
+	ticker := time.NewTicker(stallTimeout / 3)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if !s.downloads.expire(now) {
+			return
+		}
+
+		s.downloads.assign(s.knownTips(), now)
+	}
+}
+
+// bestPeer returns the outgoing queue of the peer with the highest known tip,
+// or nil if no peer serving blocks is known
+func (s *Counter) bestPeer() (chan<- *bytes.Buffer, uint64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var best chan<- *bytes.Buffer
+	var bestHeight uint64
+	for peer, height := range s.tips {
+		if best == nil || height > bestHeight {
+			best, bestHeight = peer, height
+		}
+	}
+
+	return best, bestHeight
+}
+
 func (s *Counter) listenForTimer(timer *time.Timer) {
 	select {
 	case <-timer.C:
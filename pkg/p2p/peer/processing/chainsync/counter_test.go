@@ -1,6 +1,7 @@
 package chainsync
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
@@ -32,3 +33,25 @@ func TestStopTimerGoroutine(t *testing.T) {
 	time.Sleep(1 * time.Second)
 	assert.Equal(t, uint64(1), c.blocksRemaining)
 }
+
+// The peer with the highest known tip should be picked for syncing.
+func TestBestPeer(t *testing.T) {
+	c := NewCounter(wire.NewEventBus())
+	a := make(chan *bytes.Buffer)
+	b := make(chan *bytes.Buffer)
+
+	c.setTip(a, 10)
+	c.setTip(b, 20)
+	// tips never go backwards
+	c.setTip(b, 5)
+	c.setTip(a, 15)
+
+	peer, height := c.bestPeer()
+	assert.Equal(t, (chan<- *bytes.Buffer)(b), peer)
+	assert.Equal(t, uint64(20), height)
+
+	c.forgetTip(b)
+	peer, height = c.bestPeer()
+	assert.Equal(t, (chan<- *bytes.Buffer)(a), peer)
+	assert.Equal(t, uint64(15), height)
+}
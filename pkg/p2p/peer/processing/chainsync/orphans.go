@@ -0,0 +1,157 @@
+package chainsync
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+)
+
+var (
+	// maxOrphans is the maximum amount of blocks kept in the orphan pool
+	maxOrphans = 100
+	// maxOrphansPerPeer is the maximum amount of orphans relayed by a single
+	// peer kept in the pool, so that a peer can not fill it up
+	maxOrphansPerPeer = 20
+	// orphanExpiry is the time an orphan is kept in the pool
+	orphanExpiry = 10 * time.Minute
+)
+
+// orphan is a block whose parent is not part of our chain yet
+type orphan struct {
+	hash   string
+	parent string
+	blk    *bytes.Buffer
+	peer   chan<- *bytes.Buffer
+	added  time.Time
+}
+
+// orphanPool keeps the blocks received ahead of our chain tip, until their
+// parent is accepted.
+type orphanPool struct {
+	lock sync.Mutex
+	// orphans by hex encoded hash
+	byHash map[string]*orphan
+	// orphans by hex encoded parent hash
+	byParent map[string][]*orphan
+	// amount of orphans relayed, by peer
+	perPeer map[chan<- *bytes.Buffer]int
+}
+
+func newOrphanPool() *orphanPool {
+	return &orphanPool{
+		byHash:   make(map[string]*orphan),
+		byParent: make(map[string][]*orphan),
+		perPeer:  make(map[chan<- *bytes.Buffer]int),
+	}
+}
+
+// add an orphan relayed by peer. It returns false if the orphan was not added,
+// because it is known already, or the peer relayed too many of them.
+func (p *orphanPool) add(header *block.Header, blk *bytes.Buffer, peer chan<- *bytes.Buffer, now time.Time) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	hash := hex.EncodeToString(header.Hash)
+	if _, ok := p.byHash[hash]; ok {
+		return false
+	}
+
+	p.expire(now)
+	if p.perPeer[peer] >= maxOrphansPerPeer {
+		return false
+	}
+
+	for len(p.byHash) >= maxOrphans {
+		p.evictOldest()
+	}
+
+	o := &orphan{
+		hash:   hash,
+		parent: hex.EncodeToString(header.PrevBlockHash),
+		blk:    blk,
+		peer:   peer,
+		added:  now,
+	}
+
+	p.byHash[hash] = o
+	p.byParent[o.parent] = append(p.byParent[o.parent], o)
+	p.perPeer[peer]++
+	return true
+}
+
+// children removes the orphans whose parent is the given block from the pool,
+// and returns them
+func (p *orphanPool) children(parent []byte) []*bytes.Buffer {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	orphans := p.byParent[hex.EncodeToString(parent)]
+	blocks := make([]*bytes.Buffer, 0, len(orphans))
+	for _, o := range orphans {
+		blocks = append(blocks, o.blk)
+	}
+
+	for _, o := range orphans {
+		p.remove(o)
+	}
+
+	return blocks
+}
+
+// size returns the amount of orphans in the pool
+func (p *orphanPool) size() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.byHash)
+}
+
+// expire removes the orphans older than orphanExpiry. Must be called with
+// the lock held.
+func (p *orphanPool) expire(now time.Time) {
+	for _, o := range p.byHash {
+		if now.Sub(o.added) > orphanExpiry {
+			p.remove(o)
+		}
+	}
+}
+
+// evictOldest removes the oldest orphan. Must be called with the lock held.
+func (p *orphanPool) evictOldest() {
+	var oldest *orphan
+	for _, o := range p.byHash {
+		if oldest == nil || o.added.Before(oldest.added) {
+			oldest = o
+		}
+	}
+
+	if oldest != nil {
+		p.remove(oldest)
+	}
+}
+
+// remove an orphan from the pool. Must be called with the lock held.
+func (p *orphanPool) remove(o *orphan) {
+	delete(p.byHash, o.hash)
+
+	siblings := p.byParent[o.parent]
+	for i, s := range siblings {
+		if s == o {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+
+	if len(siblings) == 0 {
+		delete(p.byParent, o.parent)
+	} else {
+		p.byParent[o.parent] = siblings
+	}
+
+	p.perPeer[o.peer]--
+	if p.perPeer[o.peer] <= 0 {
+		delete(p.perPeer, o.peer)
+	}
+}
@@ -0,0 +1,72 @@
+package chainsync
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// The orphans should be published once their parent is accepted.
+func TestConnectOrphans(t *testing.T) {
+	bus := wire.NewEventBus()
+	c := NewCounter(bus)
+	blockChan := make(chan *bytes.Buffer, 1)
+	bus.Subscribe(string(topics.Block), blockChan)
+
+	parent := helper.RandomBlock(t, 1, 1)
+	child := helper.RandomBlock(t, 2, 1)
+	child.Header.PrevBlockHash = parent.Header.Hash
+	peer := make(chan *bytes.Buffer)
+	assert.True(t, c.orphans.add(child.Header, bytes.NewBufferString("child"), peer, time.Now()))
+
+	// a duplicate is not added twice
+	assert.False(t, c.orphans.add(child.Header, bytes.NewBufferString("child"), peer, time.Now()))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, parent.Encode(buf))
+	bus.Publish(string(topics.AcceptedBlock), buf)
+
+	select {
+	case blk := <-blockChan:
+		assert.Equal(t, "child", blk.String())
+	case <-time.After(time.Second):
+		t.Fatal("orphan was not connected")
+	}
+
+	assert.Equal(t, 0, c.orphans.size())
+}
+
+// The pool should be bounded in size, per peer and in time.
+func TestOrphanPoolLimits(t *testing.T) {
+	maxOrphans = 3
+	maxOrphansPerPeer = 2
+	p := newOrphanPool()
+	a := make(chan *bytes.Buffer)
+	b := make(chan *bytes.Buffer)
+	now := time.Now()
+
+	assert.True(t, p.add(orphanHeader(t, 5), new(bytes.Buffer), a, now))
+	assert.True(t, p.add(orphanHeader(t, 6), new(bytes.Buffer), a, now.Add(time.Second)))
+	assert.False(t, p.add(orphanHeader(t, 7), new(bytes.Buffer), a, now.Add(time.Second)))
+
+	// the oldest orphan makes room for the new ones
+	assert.True(t, p.add(orphanHeader(t, 7), new(bytes.Buffer), b, now.Add(2*time.Second)))
+	assert.True(t, p.add(orphanHeader(t, 8), new(bytes.Buffer), b, now.Add(3*time.Second)))
+	assert.Equal(t, 3, p.size())
+
+	// expired orphans are dropped
+	assert.True(t, p.add(orphanHeader(t, 9), new(bytes.Buffer), a, now.Add(orphanExpiry+4*time.Second)))
+	assert.Equal(t, 1, p.size())
+}
+
+func orphanHeader(t *testing.T, height uint64) *block.Header {
+	h := helper.RandomHeader(t, height)
+	assert.NoError(t, h.SetHash())
+	return h
+}
@@ -0,0 +1,399 @@
+package chainsync
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+var (
+	// windowSize is the amount of blocks requested at once from a peer
+	windowSize = 16
+	// maxWindows is the maximum amount of windows in flight per peer
+	maxWindows = 4
+	// maxLookahead bounds the distance between the next block to feed to the
+	// chain and the blocks being downloaded, so that a slow peer can not make
+	// us buffer the whole range
+	maxLookahead uint64 = 256
+	// stallTimeout is the time a peer has to deliver a window, before the
+	// window is handed to another peer
+	stallTimeout = 15 * time.Second
+)
+
+type item struct {
+	height uint64
+	hash   []byte
+}
+
+// window is a range of blocks requested from a single peer
+type window struct {
+	peer     chan<- *bytes.Buffer
+	items    []item
+	pending  int
+	deadline time.Time
+}
+
+// scheduler downloads a range of blocks from several peers in parallel. The
+// range is learned from the inventory sent in response to a GetBlocks, or from
+// the headers sent in response to a GetHeaders, and is
+// split in windows which are requested from the peers serving blocks. The
+// downloaded blocks are handed back in height order, so that they can be fed
+// to the chain.
+// The range starts above the latest block of our locator the peer knows of,
+// which is below our tip if we are on a fork.
+type scheduler struct {
+	lock sync.Mutex
+	// the peer asked for the hashes of the range, along with our tip and the
+	// locator sent to the peer
+	hashPeer     chan<- *bytes.Buffer
+	hashTip      *block.Header
+	hashLocators [][]byte
+	// the heights of the range are known. The heights of an inventory are
+	// only known once its first block is delivered.
+	anchored bool
+
+	// items not assigned to any peer yet, ordered by height
+	queue []item
+	// windows in flight, by hex encoded block hash
+	inFlight map[string]*window
+	// amount of windows in flight, by peer
+	windows map[chan<- *bytes.Buffer]int
+	// peers which let a window expire, and are not assigned any until then
+	stalled map[chan<- *bytes.Buffer]time.Time
+	// downloaded blocks waiting for the blocks below them
+	received map[uint64]*bytes.Buffer
+	// height of the next block to hand back
+	next uint64
+	// the expiry of the windows is being watched
+	watching bool
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		inFlight: make(map[string]*window),
+		windows:  make(map[chan<- *bytes.Buffer]int),
+		stalled:  make(map[chan<- *bytes.Buffer]time.Time),
+		received: make(map[uint64]*bytes.Buffer),
+	}
+}
+
+// expectHashes records that the hashes of the blocks following the given
+// locator were requested from peer, while our tip was at tip
+func (s *scheduler) expectHashes(peer chan<- *bytes.Buffer, tip *block.Header, locators [][]byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hashPeer = peer
+	s.hashTip = tip
+	s.hashLocators = locators
+}
+
+// expected returns our tip and the locator sent along with the request of the
+// range to peer, or a nil tip if no range was requested from peer
+func (s *scheduler) expected(peer chan<- *bytes.Buffer) (*block.Header, [][]byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.hashPeer == nil || s.hashPeer != peer {
+		return nil, nil
+	}
+
+	return s.hashTip, s.hashLocators
+}
+
+// cancelHashes drops the expectation of the hashes requested from peer
+func (s *scheduler) cancelHashes(peer chan<- *bytes.Buffer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.hashPeer == peer {
+		s.hashPeer = nil
+	}
+}
+
+// onHashes takes the block hashes advertised by peer, starting at height
+// first, and returns the height of the last one. A first height of 0 means it
+// is not known, in which case the hashes are assumed to follow our tip until
+// the first block is delivered. It returns false if they are not the range we
+// asked the peer for, in which case they should be handled like any other
+// inventory.
+func (s *scheduler) onHashes(peer chan<- *bytes.Buffer, hashes [][]byte, first uint64) (uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.hashPeer == nil || s.hashPeer != peer || len(hashes) == 0 {
+		return 0, false
+	}
+
+	s.hashPeer = nil
+	s.anchored = first != 0
+	if !s.anchored {
+		first = s.hashTip.Height + 1
+	}
+
+	s.queue = make([]item, 0, len(hashes))
+	for i, hash := range hashes {
+		s.queue = append(s.queue, item{height: first + uint64(i), hash: hash})
+	}
+
+	// forget anything left from a previous range
+	s.inFlight = make(map[string]*window)
+	s.windows = make(map[chan<- *bytes.Buffer]int)
+	s.received = make(map[uint64]*bytes.Buffer)
+	s.next = first
+	return first + uint64(len(hashes)) - 1, true
+}
+
+// expects returns true if the block is being downloaded
+func (s *scheduler) expects(hash []byte) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, ok := s.inFlight[hex.EncodeToString(hash)]
+	return ok
+}
+
+// assign windows of the queue to the peers with spare capacity. tips are the
+// known chain tips of the peers serving blocks: a window is only assigned to a
+// peer which advertised all of its blocks.
+func (s *scheduler) assign(tips map[chan<- *bytes.Buffer]uint64, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// the peers with the fewest windows in flight are served first
+	peers := make([]chan<- *bytes.Buffer, 0, len(tips))
+	for peer := range tips {
+		if now.Before(s.stalled[peer]) {
+			continue
+		}
+
+		peers = append(peers, peer)
+	}
+
+	sort.Slice(peers, func(i, j int) bool { return s.windows[peers[i]] < s.windows[peers[j]] })
+
+	for assigned := true; assigned && len(s.queue) > 0; {
+		assigned = false
+		for _, peer := range peers {
+			if len(s.queue) == 0 || s.windows[peer] >= maxWindows {
+				continue
+			}
+
+			n := windowSize
+			if n > len(s.queue) {
+				n = len(s.queue)
+			}
+
+			last := s.queue[n-1].height
+			if last > tips[peer] || last >= s.next+maxLookahead {
+				continue
+			}
+
+			w := &window{peer: peer, items: s.queue[:n:n], pending: n, deadline: now.Add(stallTimeout)}
+			if err := requestWindow(w); err != nil {
+				log.WithError(err).Debugln("could not request blocks")
+				continue
+			}
+
+			s.queue = s.queue[n:]
+			s.windows[peer]++
+			for _, it := range w.items {
+				s.inFlight[hex.EncodeToString(it.hash)] = w
+			}
+
+			assigned = true
+		}
+	}
+}
+
+// deliver a block downloaded from peer. The hash should be computed from the
+// block, rather than read from it. It returns the blocks which can be fed to
+// the chain, in height order.
+func (s *scheduler) deliver(peer chan<- *bytes.Buffer, hash []byte, height uint64, blk *bytes.Buffer) []*bytes.Buffer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// only the peer the block was requested from can deliver it
+	key := hex.EncodeToString(hash)
+	w, ok := s.inFlight[key]
+	if !ok || w.peer != peer {
+		return nil
+	}
+
+	var expected uint64
+	for _, it := range w.items {
+		if bytes.Equal(it.hash, hash) {
+			expected = it.height
+		}
+	}
+
+	// the first block delivered from an inventory tells where the range
+	// starts, which can only be at or below the height we assumed
+	if !s.anchored && height > 0 && height <= expected {
+		s.anchor(expected - height)
+		expected = height
+	}
+
+	// the block does not sit where the peer advertised it, it will be
+	// requested again when the window expires
+	if height != expected {
+		return nil
+	}
+
+	delete(s.inFlight, key)
+	s.received[height] = blk
+	w.pending--
+	if w.pending == 0 {
+		s.release(w.peer)
+	}
+
+	ready := make([]*bytes.Buffer, 0)
+	for {
+		b, ok := s.received[s.next]
+		if !ok {
+			break
+		}
+
+		delete(s.received, s.next)
+		ready = append(ready, b)
+		s.next++
+	}
+
+	return ready
+}
+
+// expire hands the windows which were not delivered in time back to the
+// queue. It returns false once there is nothing left to download, and the
+// expiry does not need to be watched any longer.
+func (s *scheduler) expire(now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	expired := make(map[*window]bool)
+	for _, w := range s.inFlight {
+		if now.After(w.deadline) {
+			expired[w] = true
+		}
+	}
+
+	for w := range expired {
+		s.stalled[w.peer] = now.Add(stallTimeout)
+		s.requeue(w)
+	}
+
+	active := len(s.queue) > 0 || len(s.inFlight) > 0
+	if !active {
+		s.watching = false
+	}
+
+	return active
+}
+
+// removePeer hands the windows of a disconnected peer back to the queue
+func (s *scheduler) removePeer(peer chan<- *bytes.Buffer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.hashPeer == peer {
+		s.hashPeer = nil
+	}
+
+	windows := make(map[*window]bool)
+	for _, w := range s.inFlight {
+		if w.peer == peer {
+			windows[w] = true
+		}
+	}
+
+	for w := range windows {
+		s.requeue(w)
+	}
+
+	delete(s.windows, peer)
+	delete(s.stalled, peer)
+}
+
+// startWatching returns true if the caller should start watching the expiry
+// of the windows
+func (s *scheduler) startWatching() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.watching {
+		return false
+	}
+
+	s.watching = true
+	return true
+}
+
+// anchor lowers the heights of the range by n. Must be called with the lock
+// held, before any block of the range is delivered.
+func (s *scheduler) anchor(n uint64) {
+	for i := range s.queue {
+		s.queue[i].height -= n
+	}
+
+	lowered := make(map[*window]bool)
+	for _, w := range s.inFlight {
+		if lowered[w] {
+			continue
+		}
+
+		lowered[w] = true
+		for i := range w.items {
+			w.items[i].height -= n
+		}
+	}
+
+	s.next -= n
+	s.anchored = true
+}
+
+// requeue the undelivered items of a window. Must be called with the lock held.
+func (s *scheduler) requeue(w *window) {
+	for _, it := range w.items {
+		key := hex.EncodeToString(it.hash)
+		if s.inFlight[key] == w {
+			delete(s.inFlight, key)
+			s.queue = append(s.queue, it)
+		}
+	}
+
+	sort.Slice(s.queue, func(i, j int) bool { return s.queue[i].height < s.queue[j].height })
+	s.release(w.peer)
+}
+
+// release a window slot of peer. Must be called with the lock held.
+func (s *scheduler) release(peer chan<- *bytes.Buffer) {
+	if s.windows[peer] > 0 {
+		s.windows[peer]--
+	}
+}
+
+// requestWindow sends a GetData for the blocks of w to its peer
+func requestWindow(w *window) error {
+	getData := &peermsg.Inv{}
+	for _, it := range w.items {
+		getData.AddItem(peermsg.InvTypeBlock, it.hash)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := getData.Encode(buf); err != nil {
+		return err
+	}
+
+	msg, err := wire.AddTopic(buf, topics.GetData)
+	if err != nil {
+		return err
+	}
+
+	// the queue of another peer may be full
+	select {
+	case w.peer <- msg:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
@@ -0,0 +1,150 @@
+package chainsync
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+func testHashes(n int) [][]byte {
+	hashes := make([][]byte, n)
+	for i := range hashes {
+		hashes[i] = bytes.Repeat([]byte{byte(i + 1)}, 32)
+	}
+
+	return hashes
+}
+
+// requested decodes the hashes of the GetData messages sent to a peer
+func requested(t *testing.T, peer chan *bytes.Buffer) [][]byte {
+	hashes := make([][]byte, 0)
+	for len(peer) > 0 {
+		msg := <-peer
+		var topicBytes [15]byte
+		copy(topicBytes[:], msg.Next(15))
+		assert.Equal(t, topics.GetData, topics.ByteArrayToTopic(topicBytes))
+
+		inv := &peermsg.Inv{}
+		assert.NoError(t, inv.Decode(msg))
+		for _, item := range inv.InvList {
+			hashes = append(hashes, item.Hash)
+		}
+	}
+
+	return hashes
+}
+
+// The range should be split across the peers, and the blocks handed back in
+// height order.
+func TestScheduleAcrossPeers(t *testing.T) {
+	windowSize = 2
+	a := make(chan *bytes.Buffer, 10)
+	b := make(chan *bytes.Buffer, 10)
+	tips := map[chan<- *bytes.Buffer]uint64{a: 10, b: 10}
+
+	s := newScheduler()
+	hashes := testHashes(4)
+
+	// only the inventory of the peer we asked is scheduled
+	_, ok := s.onHashes(a, hashes, 6)
+	assert.False(t, ok)
+	s.expectHashes(a, &block.Header{Height: 5}, nil)
+	_, ok = s.onHashes(b, hashes, 6)
+	assert.False(t, ok)
+	top, ok := s.onHashes(a, hashes, 6)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(9), top)
+
+	s.assign(tips, time.Now())
+	fromA, fromB := requested(t, a), requested(t, b)
+	assert.Equal(t, 2, len(fromA))
+	assert.Equal(t, 2, len(fromB))
+
+	owners := make(map[string]chan *bytes.Buffer)
+	for _, hash := range fromA {
+		owners[string(hash)] = a
+	}
+
+	for _, hash := range fromB {
+		owners[string(hash)] = b
+	}
+
+	// the blocks are only accepted from the peer they were requested from
+	other := map[chan *bytes.Buffer]chan *bytes.Buffer{a: b, b: a}
+	assert.Empty(t, s.deliver(other[owners[string(hashes[0])]], hashes[0], 6, new(bytes.Buffer)))
+
+	// heights 6 to 9, delivered backwards
+	var fed []*bytes.Buffer
+	for i := 3; i >= 0; i-- {
+		fed = append(fed, s.deliver(owners[string(hashes[i])], hashes[i], uint64(6+i), bytes.NewBuffer([]byte{byte(i)}))...)
+	}
+
+	assert.Equal(t, 4, len(fed))
+	for i, blk := range fed {
+		assert.Equal(t, []byte{byte(i)}, blk.Bytes())
+	}
+
+	assert.False(t, s.expire(time.Now()))
+}
+
+// A window which is not delivered in time should be handed to another peer.
+func TestReassignStalled(t *testing.T) {
+	windowSize = 2
+	a := make(chan *bytes.Buffer, 10)
+	b := make(chan *bytes.Buffer, 10)
+
+	s := newScheduler()
+	hashes := testHashes(2)
+	s.expectHashes(a, &block.Header{}, nil)
+	_, ok := s.onHashes(a, hashes, 0)
+	assert.True(t, ok)
+
+	// b does not have the blocks yet
+	now := time.Now()
+	s.assign(map[chan<- *bytes.Buffer]uint64{a: 2, b: 1}, now)
+	assert.Equal(t, 2, len(requested(t, a)))
+	assert.Equal(t, 0, len(requested(t, b)))
+
+	// a delivers one block, and stalls
+	assert.Equal(t, 1, len(s.deliver(a, hashes[0], 1, new(bytes.Buffer))))
+	later := now.Add(stallTimeout + time.Second)
+	assert.True(t, s.expire(later))
+
+	s.assign(map[chan<- *bytes.Buffer]uint64{a: 2, b: 2}, later)
+	assert.Equal(t, 0, len(requested(t, a)))
+	assert.Equal(t, [][]byte{hashes[1]}, requested(t, b))
+
+	assert.Equal(t, 0, len(s.deliver(a, hashes[1], 2, new(bytes.Buffer))))
+	assert.Equal(t, 1, len(s.deliver(b, hashes[1], 2, new(bytes.Buffer))))
+	assert.False(t, s.expire(later))
+}
+
+// The heights of an inventory should be learned from the first block
+// delivered, as the range may fork below our tip.
+func TestAnchorInventory(t *testing.T) {
+	windowSize = 4
+	a := make(chan *bytes.Buffer, 10)
+
+	s := newScheduler()
+	hashes := testHashes(3)
+	s.expectHashes(a, &block.Header{Height: 10}, nil)
+	top, ok := s.onHashes(a, hashes, 0)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(13), top)
+
+	s.assign(map[chan<- *bytes.Buffer]uint64{a: 13}, time.Now())
+	assert.Equal(t, 3, len(requested(t, a)))
+
+	// the range turns out to start at height 4
+	assert.Empty(t, s.deliver(a, hashes[1], 5, new(bytes.Buffer)))
+	assert.Equal(t, 2, len(s.deliver(a, hashes[0], 4, new(bytes.Buffer))))
+
+	// and the other blocks must sit at the heights following it
+	assert.Empty(t, s.deliver(a, hashes[2], 7, new(bytes.Buffer)))
+	assert.Equal(t, 1, len(s.deliver(a, hashes[2], 6, new(bytes.Buffer))))
+}
@@ -5,16 +5,25 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
+	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	logger "github.com/sirupsen/logrus"
 )
 
 var log *logger.Entry = logger.WithFields(logger.Fields{"process": "synchronizer"})
 
+var errQueueFull = errors.New("outgoing queue of the peer is full")
+
+// maxTimeDrift is how far in the future the timestamp of a header may be
+var maxTimeDrift = 5 * time.Minute
+
 // ChainSynchronizer is the component responsible for keeping the node in sync with the
 // rest of the network. It sits between the peer and the chain, as a sort of gateway for
 // incoming blocks. It keeps track of the local chain tip and compares it with each incoming
@@ -24,6 +33,8 @@ type ChainSynchronizer struct {
 	rpcBus    *wire.RPCBus
 	*Counter
 	responseChan chan<- *bytes.Buffer
+	// the peer serves full blocks, and can be asked for them
+	servesBlocks bool
 }
 
 // NewChainSynchronizer returns an initialized ChainSynchronizer. The passed responseChan
@@ -35,11 +46,186 @@ func NewChainSynchronizer(publisher wire.EventPublisher, rpcBus *wire.RPCBus, re
 		rpcBus:       rpcBus,
 		Counter:      counter,
 		responseChan: responseChan,
+		servesBlocks: true,
+	}
+}
+
+// Advertised records what the peer advertised during the handshake: the height
+// of its chain tip, whether it serves full blocks, and the protocol features it
+// supports. If the peer is sufficiently ahead of us, and we are not syncing
+// yet, the missing blocks are requested from the peer with the highest known
+// tip.
+func (s *ChainSynchronizer) Advertised(height uint64, servesBlocks bool, features protocol.Features) error {
+	s.servesBlocks = servesBlocks
+	s.setFeatures(s.responseChan, features)
+	if !servesBlocks {
+		return nil
+	}
+
+	s.setTip(s.responseChan, height)
+	blk, err := s.getLastBlock()
+	if err != nil {
+		return err
+	}
+
+	if !s.isSyncing() && compareHeights(blk.Header.Height, height) > 1 {
+		return s.requestMissingBlocks(blk, height)
+	}
+
+	return nil
+}
+
+// Forget the tip of the peer, once it is disconnected
+func (s *ChainSynchronizer) Forget() {
+	s.forgetTip(s.responseChan)
+}
+
+// OnInv takes the inventories received from the peer. If the inventory lists
+// the blocks we asked the peer for with a GetBlocks, their download is
+// scheduled across all the peers serving blocks, and true is returned.
+// Otherwise, the inventory is left untouched.
+func (s *ChainSynchronizer) OnInv(m *bytes.Buffer) bool {
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(bytes.NewReader(m.Bytes())); err != nil {
+		return false
 	}
+
+	hashes := make([][]byte, 0, len(inv.InvList))
+	for _, item := range inv.InvList {
+		if item.Type != peermsg.InvTypeBlock {
+			return false
+		}
+
+		hashes = append(hashes, item.Hash)
+	}
+
+	// the inventory does not tell the heights of the blocks
+	return s.download(hashes, 0)
+}
+
+// OnHeaders takes the headers received from the peer in response to a
+// GetHeaders. The headers are checked to follow each other and our locator,
+// and their certificates are verified, before the download of the blocks is
+// scheduled. onInvalid is called if the peer sent headers which can not be
+// part of the chain.
+func (s *ChainSynchronizer) OnHeaders(m *bytes.Buffer, onInvalid func(reason string)) error {
+	// unsolicited headers are ignored
+	tip, locators := s.downloads.expected(s.responseChan)
+	if tip == nil {
+		return nil
+	}
+
+	msg := &peermsg.Headers{}
+	if err := msg.Decode(m); err != nil {
+		return err
+	}
+
+	headers, err := checkHeaders(tip, locators, msg.Headers, time.Now())
+	if err != nil {
+		onInvalid(err.Error())
+	}
+
+	// the certificates can only be verified against the current committee,
+	// so only the first one is expected to pass for sure
+	if len(headers) > 0 {
+		valid, err := s.verifyCertificates(headers)
+		if err != nil {
+			s.downloads.cancelHashes(s.responseChan)
+			return err
+		}
+
+		if valid == 0 {
+			onInvalid("invalid certificate")
+		}
+
+		headers = headers[:valid]
+	}
+
+	if len(headers) == 0 {
+		s.downloads.cancelHashes(s.responseChan)
+		return nil
+	}
+
+	hashes := make([][]byte, len(headers))
+	for i, header := range headers {
+		hashes[i] = header.Hash
+	}
+
+	s.download(hashes, headers[0].Height)
+	return nil
+}
+
+// download schedules the download of the hashes requested from the peer,
+// across all the peers serving blocks. first is the height of the first hash,
+// or 0 if it is not known. It returns false if they are not the hashes we
+// asked the peer for.
+func (s *ChainSynchronizer) download(hashes [][]byte, first uint64) bool {
+	top, ok := s.downloads.onHashes(s.responseChan, hashes, first)
+	if !ok {
+		return false
+	}
+
+	// the peer has at least the blocks it advertised
+	s.setTip(s.responseChan, top)
+
+	log.Debugf("downloading %d blocks", len(hashes))
+	s.downloads.assign(s.knownTips(), time.Now())
+	if s.downloads.startWatching() {
+		go s.watchDownloads()
+	}
+
+	return true
+}
+
+// verifyCertificates returns the amount of leading headers with a valid
+// certificate
+func (s *ChainSynchronizer) verifyCertificates(headers []*block.Header) (int, error) {
+	buf := new(bytes.Buffer)
+	if err := (&peermsg.Headers{Headers: headers}).Encode(buf); err != nil {
+		return 0, err
+	}
+
+	resp, err := s.rpcBus.Call(wire.VerifyHeaders, wire.NewRequest(*buf, 5))
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.Len() < 8 {
+		return 0, errors.New("malformed verifyHeaders response")
+	}
+
+	valid := binary.LittleEndian.Uint64(resp.Bytes())
+	if valid > uint64(len(headers)) {
+		valid = uint64(len(headers))
+	}
+
+	return int(valid), nil
 }
 
 // Synchronize our blockchain with our peers.
 func (s *ChainSynchronizer) Synchronize(blkBuf *bytes.Buffer, peerInfo string) error {
+	// the blocks we are downloading are fed to the chain in height order
+	header := &block.Header{}
+	if err := header.Decode(bytes.NewReader(blkBuf.Bytes())); err != nil {
+		return err
+	}
+
+	// the hash sent along with the header is not trusted
+	if err := header.SetHash(); err != nil {
+		return err
+	}
+
+	if s.downloads.expects(header.Hash) {
+		for _, ready := range s.downloads.deliver(s.responseChan, header.Hash, header.Height, blkBuf) {
+			s.publisher.Publish(string(topics.Block), ready)
+		}
+
+		s.downloads.assign(s.knownTips(), time.Now())
+		return nil
+	}
+
+	// keep the encoded block around, in case it ends up in the orphan pool
+	raw := blkBuf.Bytes()
 	r := bufio.NewReader(blkBuf)
 	height, err := peekBlockHeight(r)
 	if err != nil {
@@ -52,24 +238,21 @@ func (s *ChainSynchronizer) Synchronize(blkBuf *bytes.Buffer, peerInfo string) e
 	}
 
 	log.WithField("our height", blk.Header.Height).WithField("received block height", height).Debugln("block received")
+	if s.servesBlocks {
+		s.setTip(s.responseChan, height)
+	}
+
 	// Only ask for missing blocks if we are not currently syncing, to prevent
 	// asking many peers for (generally) the same blocks.
 	diff := compareHeights(blk.Header.Height, height)
-	if !s.isSyncing() && diff > 1 {
-
-		hash := base64.StdEncoding.EncodeToString(blk.Header.Hash)
-		log.Debugf("Start syncing from %s", peerInfo)
-		log.Debugf("Local tip: height %d [%s]", blk.Header.Height, hash)
-
-		msg := createGetBlocksMsg(blk.Header.Hash)
-		buf, err := marshalGetBlocks(msg)
-		if err != nil {
-			return err
-		}
+	if diff > 1 {
+		// the block is connected once its parent is accepted
+		s.orphans.add(header, bytes.NewBuffer(raw), s.responseChan, time.Now())
+	}
 
-		s.responseChan <- buf
-		s.startSyncing(uint64(diff))
-		return nil
+	if !s.isSyncing() && diff > 1 {
+		log.Debugf("%s is ahead of us", peerInfo)
+		return s.requestMissingBlocks(blk, height)
 	}
 
 	if diff == 1 {
@@ -85,6 +268,61 @@ func (s *ChainSynchronizer) Synchronize(blkBuf *bytes.Buffer, peerInfo string) e
 	return nil
 }
 
+// requestMissingBlocks asks for the blocks following our tip. Peers which
+// advertised a higher tip than height are preferred, and peers which do not
+// serve full blocks are never asked.
+func (s *ChainSynchronizer) requestMissingBlocks(blk *block.Block, height uint64) error {
+	target, tip := s.bestPeer()
+	if s.servesBlocks && height >= tip {
+		target = s.responseChan
+	}
+
+	if target == nil {
+		log.Debugln("no peer serving blocks to sync from")
+		return nil
+	}
+
+	if tip > height {
+		height = tip
+	}
+
+	hash := base64.StdEncoding.EncodeToString(blk.Header.Hash)
+	log.Debugf("Start syncing up to height %d", height)
+	log.Debugf("Local tip: height %d [%s]", blk.Header.Height, hash)
+
+	locators, err := s.getLocators()
+	if err != nil {
+		return err
+	}
+
+	// the headers are checked before any block is requested, if the peer
+	// can serve them
+	var buf *bytes.Buffer
+	if s.supports(target, protocol.FeatureHeaders) {
+		buf, err = marshalGetHeaders(&peermsg.GetHeaders{Locators: locators})
+	} else {
+		buf, err = marshalGetBlocks(&peermsg.GetBlocks{Locators: locators})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// another peer's queue may be full, or no longer served
+	select {
+	case target <- buf:
+	default:
+		return errQueueFull
+	}
+
+	// the blocks will be downloaded from all the peers, once the sync peer
+	// advertises them
+	s.downloads.expectHashes(target, blk.Header, locators)
+
+	s.startSyncing(uint64(compareHeights(blk.Header.Height, height)))
+	return nil
+}
+
 func (s *ChainSynchronizer) getLastBlock() (*block.Block, error) {
 	req := wire.NewRequest(bytes.Buffer{}, 2)
 	blkBuf, err := s.rpcBus.Call(wire.GetLastBlock, req)
@@ -100,14 +338,25 @@ func (s *ChainSynchronizer) getLastBlock() (*block.Block, error) {
 	return blk, nil
 }
 
-func compareHeights(ourHeight, theirHeight uint64) int64 {
-	return int64(theirHeight) - int64(ourHeight)
-}
+// getLocators returns the locator of our chain, so that the peer can find the
+// latest block we have in common
+func (s *ChainSynchronizer) getLocators() ([][]byte, error) {
+	req := wire.NewRequest(bytes.Buffer{}, 2)
+	buf, err := s.rpcBus.Call(wire.GetBlockLocator, req)
+	if err != nil {
+		return nil, err
+	}
 
-func createGetBlocksMsg(latestHash []byte) *peermsg.GetBlocks {
 	msg := &peermsg.GetBlocks{}
-	msg.Locators = append(msg.Locators, latestHash)
-	return msg
+	if err := msg.Decode(&buf); err != nil {
+		return nil, err
+	}
+
+	return msg.Locators, nil
+}
+
+func compareHeights(ourHeight, theirHeight uint64) int64 {
+	return int64(theirHeight) - int64(ourHeight)
 }
 
 func marshalGetBlocks(msg *peermsg.GetBlocks) (*bytes.Buffer, error) {
@@ -119,6 +368,68 @@ func marshalGetBlocks(msg *peermsg.GetBlocks) (*bytes.Buffer, error) {
 	return wire.AddTopic(buf, topics.GetBlocks)
 }
 
+func marshalGetHeaders(msg *peermsg.GetHeaders) (*bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	if err := msg.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	return wire.AddTopic(buf, topics.GetHeaders)
+}
+
+// checkHeaders returns the leading headers which follow each other, starting
+// from a block of the locator. The headers too far in the future are left
+// out, as they may be valid later on. An error is returned if a header can not
+// follow the previous one.
+// The first header follows our tip, unless the peer is on a fork. As we do not
+// hold the header of the fork point, the first header of a fork is only
+// checked to sit below our tip.
+func checkHeaders(tip *block.Header, locators [][]byte, headers []*block.Header, now time.Time) ([]*block.Header, error) {
+	prev := tip
+	for i, header := range headers {
+		if err := checkHeader(prev, locators, header, i == 0); err != nil {
+			return headers[:i], err
+		}
+
+		hashed := *header
+		if err := hashed.SetHash(); err != nil {
+			return headers[:i], err
+		}
+
+		if !bytes.Equal(hashed.Hash, header.Hash) {
+			return headers[:i], errors.New("header hash mismatch")
+		}
+
+		if time.Unix(header.Timestamp, 0).After(now.Add(maxTimeDrift)) {
+			return headers[:i], nil
+		}
+
+		prev = header
+	}
+
+	return headers, nil
+}
+
+// checkHeader checks that header follows prev. If first is set, prev is our
+// tip, and the header may instead follow any other block of the locator.
+func checkHeader(prev *block.Header, locators [][]byte, header *block.Header, first bool) error {
+	if !first || bytes.Equal(header.PrevBlockHash, prev.Hash) {
+		return verifiers.CheckHeader(prev, header)
+	}
+
+	for _, locator := range locators {
+		if bytes.Equal(header.PrevBlockHash, locator) {
+			if header.Height == 0 || header.Height > prev.Height {
+				return verifiers.ErrHeightMismatch
+			}
+
+			return nil
+		}
+	}
+
+	return errors.New("header does not follow our locator")
+}
+
 func peekBlockHeight(r *bufio.Reader) (uint64, error) {
 	// The block height is a little-endian uint64, starting at index 1 of the buffer
 	// It is preceded by the version (1 byte)
@@ -2,11 +2,16 @@ package chainsync_test
 
 import (
 	"bytes"
+	"encoding/binary"
 	"testing"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing/chainsync"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 )
 
@@ -14,6 +19,7 @@ import (
 // are sufficiently behind the chain tip.
 func TestSynchronizeBehind(t *testing.T) {
 	cs, _, responseChan := setupSynchronizer(t)
+	go respondLocator(t)
 
 	// Create a block that is a few rounds in the future
 	blk := randomBlockBuffer(t, 5, 20)
@@ -53,6 +59,229 @@ func TestSynchronizeSynced(t *testing.T) {
 	<-blockChan
 }
 
+// Blocks relayed by a peer which does not serve them should be requested from
+// a peer which does.
+func TestSynchronizeFromFullNode(t *testing.T) {
+	eb := wire.NewEventBus()
+	rpcBus := wire.NewRPCBus()
+	counter := chainsync.NewCounter(eb)
+	go func() {
+		for i := 0; i < 2; i++ {
+			respond(t, rpcBus)
+		}
+
+		respondLocator(t)
+	}()
+
+	fullChan := make(chan *bytes.Buffer, 100)
+	full := chainsync.NewChainSynchronizer(eb, rpcBus, fullChan, counter)
+	lightChan := make(chan *bytes.Buffer, 100)
+	light := chainsync.NewChainSynchronizer(eb, rpcBus, lightChan, counter)
+	if err := light.Advertised(50, false, protocol.LocalFeatures); err != nil {
+		t.Fatal(err)
+	}
+
+	// the full node relays the block following our tip
+	if err := full.Synchronize(randomBlockBuffer(t, 1, 20), "full_peer"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the light node relays a block far ahead
+	if err := light.Synchronize(randomBlockBuffer(t, 5, 20), "light_peer"); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-fullChan
+	var topicBytes [15]byte
+	copy(topicBytes[:], msg.Bytes()[0:15])
+	if topics.ByteArrayToTopic(topicBytes) != topics.GetBlocks {
+		t.Fatal("did not receive expected GetBlocks message")
+	}
+
+	if len(lightChan) != 0 {
+		t.Fatal("blocks were requested from the light node")
+	}
+}
+
+// The headers should be checked before the blocks are requested, and only the
+// blocks with a verified certificate should be requested.
+func TestSyncHeadersFirst(t *testing.T) {
+	cs, genesis, responseChan := setupHeadersSync(t)
+	headers := headerChain(t, genesis.Header, 3)
+
+	// the certificate of the last header can not be verified
+	go func() {
+		r := <-wire.VerifyHeadersChan
+		buf := new(bytes.Buffer)
+		_ = encoding.WriteUint64(buf, binary.LittleEndian, 2)
+		r.RespChan <- *buf
+	}()
+
+	var invalid string
+	if err := cs.OnHeaders(headersBuffer(t, headers), func(reason string) { invalid = reason }); err != nil {
+		t.Fatal(err)
+	}
+
+	if invalid != "" {
+		t.Fatalf("valid headers reported as invalid: %s", invalid)
+	}
+
+	msg := <-responseChan
+	if readTopic(msg) != topics.GetData {
+		t.Fatal("did not receive expected GetData message")
+	}
+
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inv.InvList) != 2 {
+		t.Fatalf("expected 2 blocks to be requested, got %d", len(inv.InvList))
+	}
+
+	for i, item := range inv.InvList {
+		if !bytes.Equal(headers[i].Hash, item.Hash) {
+			t.Fatal("requested block does not match the header")
+		}
+	}
+}
+
+// Headers forking below our tip, from a block of our locator, should be
+// accepted.
+func TestSyncForkedHeaders(t *testing.T) {
+	tip := helper.RandomBlock(t, 5, 1)
+	fork := helper.RandomHeader(t, 3)
+	if err := fork.SetHash(); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, responseChan := setupHeadersSyncAt(t, tip, [][]byte{tip.Header.Hash, fork.Hash})
+	headers := headerChain(t, fork, 3)
+	go func() {
+		r := <-wire.VerifyHeadersChan
+		buf := new(bytes.Buffer)
+		_ = encoding.WriteUint64(buf, binary.LittleEndian, 3)
+		r.RespChan <- *buf
+	}()
+
+	var invalid string
+	if err := cs.OnHeaders(headersBuffer(t, headers), func(reason string) { invalid = reason }); err != nil {
+		t.Fatal(err)
+	}
+
+	if invalid != "" {
+		t.Fatalf("forked headers reported as invalid: %s", invalid)
+	}
+
+	msg := <-responseChan
+	if readTopic(msg) != topics.GetData {
+		t.Fatal("did not receive expected GetData message")
+	}
+
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inv.InvList) != 3 {
+		t.Fatalf("expected 3 blocks to be requested, got %d", len(inv.InvList))
+	}
+}
+
+// Headers which do not follow our locator should be reported, and no block
+// should be requested.
+func TestRejectUnlinkedHeaders(t *testing.T) {
+	cs, _, responseChan := setupHeadersSync(t)
+	fork := helper.RandomHeader(t, 0)
+	if err := fork.SetHash(); err != nil {
+		t.Fatal(err)
+	}
+
+	headers := headerChain(t, fork, 3)
+
+	var invalid string
+	if err := cs.OnHeaders(headersBuffer(t, headers), func(reason string) { invalid = reason }); err != nil {
+		t.Fatal(err)
+	}
+
+	if invalid == "" {
+		t.Fatal("unlinked headers were not reported")
+	}
+
+	if len(responseChan) != 0 {
+		t.Fatal("blocks were requested for invalid headers")
+	}
+}
+
+// setupHeadersSync returns a ChainSynchronizer which requested the headers
+// following the returned genesis block from its peer.
+func setupHeadersSync(t *testing.T) (*chainsync.ChainSynchronizer, *block.Block, chan *bytes.Buffer) {
+	genesis := helper.RandomBlock(t, 0, 1)
+	cs, responseChan := setupHeadersSyncAt(t, genesis, [][]byte{genesis.Header.Hash})
+	return cs, genesis, responseChan
+}
+
+// setupHeadersSyncAt returns a ChainSynchronizer which requested the headers
+// following the given locator from its peer, while its tip is at tip.
+func setupHeadersSyncAt(t *testing.T, tip *block.Block, locators [][]byte) (*chainsync.ChainSynchronizer, chan *bytes.Buffer) {
+	eb := wire.NewEventBus()
+	rpcBus := wire.NewRPCBus()
+	go func() {
+		r := <-wire.GetLastBlockChan
+		buf := new(bytes.Buffer)
+		_ = tip.Encode(buf)
+		r.RespChan <- *buf
+		respondLocators(locators)
+	}()
+
+	responseChan := make(chan *bytes.Buffer, 100)
+	cs := chainsync.NewChainSynchronizer(eb, rpcBus, responseChan, chainsync.NewCounter(eb))
+	if err := cs.Advertised(10, true, protocol.FeatureHeaders); err != nil {
+		t.Fatal(err)
+	}
+
+	if readTopic(<-responseChan) != topics.GetHeaders {
+		t.Fatal("did not receive expected GetHeaders message")
+	}
+
+	return cs, responseChan
+}
+
+// headerChain returns n headers following base
+func headerChain(t *testing.T, base *block.Header, n int) []*block.Header {
+	headers := make([]*block.Header, n)
+	prev := base
+	for i := range headers {
+		h := helper.RandomHeader(t, prev.Height+1)
+		h.PrevBlockHash = prev.Hash
+		h.Timestamp = prev.Timestamp + 1
+		if err := h.SetHash(); err != nil {
+			t.Fatal(err)
+		}
+
+		headers[i] = h
+		prev = h
+	}
+
+	return headers
+}
+
+func headersBuffer(t *testing.T, headers []*block.Header) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	if err := (&peermsg.Headers{Headers: headers}).Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
+
+func readTopic(msg *bytes.Buffer) topics.Topic {
+	var topicBytes [15]byte
+	copy(topicBytes[:], msg.Next(15))
+	return topics.ByteArrayToTopic(topicBytes)
+}
+
 // Returns an encoded representation of a `helper.RandomBlock`.
 func randomBlockBuffer(t *testing.T, height uint64, txBatchCount uint16) *bytes.Buffer {
 	blk := helper.RandomBlock(t, height, txBatchCount)
@@ -80,3 +309,16 @@ func respond(t *testing.T, rpcBus *wire.RPCBus) {
 	r := <-wire.GetLastBlockChan
 	r.RespChan <- *randomBlockBuffer(t, 0, 1)
 }
+
+// Dummy goroutine which sends a locator back when the ChainSynchronizer
+// requests one.
+func respondLocator(t *testing.T) {
+	respondLocators([][]byte{helper.RandomSlice(t, 32)})
+}
+
+func respondLocators(locators [][]byte) {
+	r := <-wire.GetBlockLocatorChan
+	buf := new(bytes.Buffer)
+	_ = (&peermsg.GetBlocks{Locators: locators}).Encode(buf)
+	r.RespChan <- *buf
+}
@@ -0,0 +1,203 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+var (
+	// ErrMalformedCompactBlock is returned when a compact block can not be
+	// decoded, or lists its txs inconsistently
+	ErrMalformedCompactBlock = errors.New("malformed compact block")
+
+	errUnexpectedBlockTxn = errors.New("unexpected blocktxn")
+)
+
+// partialBlock is a compact block waiting for the txs missing from our mempool
+type partialBlock struct {
+	header  *block.Header
+	txs     []transactions.Transaction
+	missing []uint32
+}
+
+// CompactBlocks is a processing unit which rebuilds the compact blocks received
+// from a peer, with the txs of our mempool. The txs missing from the mempool are
+// requested from the peer. It maintains a connection to the outgoing message
+// queue of the peer.
+type CompactBlocks struct {
+	rpcBus       *wire.RPCBus
+	responseChan chan<- *bytes.Buffer
+	// the block waiting for its missing txs. A peer only relays one block at
+	// a time.
+	partial *partialBlock
+}
+
+// NewCompactBlocks returns an initialized CompactBlocks.
+func NewCompactBlocks(rpcBus *wire.RPCBus, responseChan chan<- *bytes.Buffer) *CompactBlocks {
+	return &CompactBlocks{
+		rpcBus:       rpcBus,
+		responseChan: responseChan,
+	}
+}
+
+// OnCompactBlock takes a CmpctBlock message from the wire, and tries to rebuild
+// the block with the txs of our mempool. It returns the encoded block once it is
+// complete, or nil if txs had to be requested from the peer.
+func (c *CompactBlocks) OnCompactBlock(m *bytes.Buffer) (*bytes.Buffer, error) {
+	msg := &peermsg.CompactBlock{}
+	if err := msg.Decode(m); err != nil {
+		return nil, ErrMalformedCompactBlock
+	}
+
+	partial, err := c.rebuild(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(partial.missing) == 0 {
+		return c.complete(partial)
+	}
+
+	c.partial = partial
+	getBlockTxn := &peermsg.GetBlockTxn{BlockHash: partial.header.Hash, Indexes: partial.missing}
+	buf := new(bytes.Buffer)
+	if err := getBlockTxn.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	req, err := wire.AddTopic(buf, topics.GetBlockTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	c.responseChan <- req
+	return nil, nil
+}
+
+// OnBlockTxn takes a BlockTxn message from the wire, carrying the txs missing
+// from the block being rebuilt. It returns the encoded block.
+func (c *CompactBlocks) OnBlockTxn(m *bytes.Buffer) (*bytes.Buffer, error) {
+	msg := &peermsg.BlockTxn{}
+	if err := msg.Decode(m); err != nil {
+		return nil, err
+	}
+
+	partial := c.partial
+	if partial == nil || !bytes.Equal(partial.header.Hash, msg.BlockHash) {
+		return nil, errUnexpectedBlockTxn
+	}
+
+	c.partial = nil
+	if len(msg.Txs) != len(partial.missing) {
+		return nil, errors.New("blocktxn does not carry the requested txs")
+	}
+
+	for i, index := range partial.missing {
+		partial.txs[index] = msg.Txs[i]
+	}
+
+	partial.missing = nil
+	return c.complete(partial)
+}
+
+// rebuild fills the txs of a compact block in with the prefilled ones, and the
+// ones from our mempool, and lists the indexes of the txs which are missing
+func (c *CompactBlocks) rebuild(msg *peermsg.CompactBlock) (*partialBlock, error) {
+	partial := &partialBlock{
+		header: msg.Header,
+		txs:    make([]transactions.Transaction, msg.TxCount()),
+	}
+
+	for _, p := range msg.Prefilled {
+		if int(p.Index) >= len(partial.txs) || partial.txs[p.Index] != nil {
+			return nil, ErrMalformedCompactBlock
+		}
+
+		partial.txs[p.Index] = p.Tx
+	}
+
+	// txs whose short IDs collide can not be told apart, and are requested
+	mempool := make(map[string]transactions.Transaction)
+	if len(msg.ShortIDs) > 0 {
+		txs, err := GetMempoolTxs(c.rpcBus, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range txs {
+			txID, err := tx.CalculateHash()
+			if err != nil {
+				return nil, err
+			}
+
+			shortID, err := peermsg.ShortID(msg.Header.Hash, txID)
+			if err != nil {
+				return nil, err
+			}
+
+			key := hex.EncodeToString(shortID)
+			if _, ok := mempool[key]; ok {
+				mempool[key] = nil
+				continue
+			}
+
+			mempool[key] = tx
+		}
+	}
+
+	next := 0
+	for i := range partial.txs {
+		if partial.txs[i] != nil {
+			continue
+		}
+
+		tx := mempool[hex.EncodeToString(msg.ShortIDs[next])]
+		next++
+		if tx == nil {
+			partial.missing = append(partial.missing, uint32(i))
+			continue
+		}
+
+		partial.txs[i] = tx
+	}
+
+	return partial, nil
+}
+
+// complete assembles the rebuilt block. If the txs do not match the header, a
+// short ID matched the wrong tx, and the full block is requested instead.
+func (c *CompactBlocks) complete(partial *partialBlock) (*bytes.Buffer, error) {
+	blk := &block.Block{Header: partial.header, Txs: partial.txs}
+	root := blk.Header.TxRoot
+	rebuilt := *blk.Header
+	blk.Header = &rebuilt
+	if err := blk.SetRoot(); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(root, blk.Header.TxRoot) {
+		getData := &peermsg.Inv{}
+		getData.AddItem(peermsg.InvTypeBlock, partial.header.Hash)
+		buf, err := marshalGetData(getData)
+		if err != nil {
+			return nil, err
+		}
+
+		c.responseChan <- buf
+		return nil, nil
+	}
+
+	buf := new(bytes.Buffer)
+	if err := blk.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,76 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// A block should be rebuilt from a compact block and the mempool, requesting
+// only the txs missing from the mempool.
+func TestRelayCompactBlock(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+	defer db.Close()
+
+	blk := helper.RandomBlock(t, 1, 1)
+	if err := storeBlocks(db, []*block.Block{blk}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the sending side
+	senderChan := make(chan *bytes.Buffer, 10)
+	dataBroker := processing.NewDataBroker(db, nil, senderChan)
+	getData := &peermsg.Inv{}
+	getData.AddItem(peermsg.InvTypeCompactBlock, blk.Header.Hash)
+	buf := new(bytes.Buffer)
+	assert.NoError(t, getData.Encode(buf))
+	assert.NoError(t, dataBroker.SendItems(buf))
+
+	cmpctBlock := <-senderChan
+	assert.Equal(t, topics.CmpctBlock, extractTopic(cmpctBlock))
+
+	// the receiving side has all the txs but the last one in its mempool
+	rpcBus := wire.NewRPCBus()
+	go respondMempool(t, blk.Txs[1:len(blk.Txs)-1])
+
+	receiverChan := make(chan *bytes.Buffer, 10)
+	compactBlocks := processing.NewCompactBlocks(rpcBus, receiverChan)
+	rebuilt, err := compactBlocks.OnCompactBlock(cmpctBlock)
+	assert.NoError(t, err)
+	assert.Nil(t, rebuilt)
+
+	getBlockTxn := <-receiverChan
+	assert.Equal(t, topics.GetBlockTxn, extractTopic(getBlockTxn))
+	assert.NoError(t, dataBroker.SendBlockTxs(getBlockTxn))
+
+	blockTxn := <-senderChan
+	assert.Equal(t, topics.BlockTxn, extractTopic(blockTxn))
+	rebuilt, err = compactBlocks.OnBlockTxn(blockTxn)
+	assert.NoError(t, err)
+
+	decoded := block.NewBlock()
+	assert.NoError(t, decoded.Decode(rebuilt))
+	assert.True(t, blk.Equals(decoded))
+}
+
+// respondMempool answers a GetMempoolTxs request with the given txs
+func respondMempool(t *testing.T, txs []transactions.Transaction) {
+	r := <-wire.GetMempoolTxsChan
+	buf := new(bytes.Buffer)
+	assert.NoError(t, encoding.WriteVarInt(buf, uint64(len(txs))))
+	for _, tx := range txs {
+		assert.NoError(t, tx.Encode(buf))
+	}
+
+	r.RespChan <- *buf
+}
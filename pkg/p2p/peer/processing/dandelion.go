@@ -0,0 +1,218 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// fluffProbability is the probability that a tx relayed to us in its stem
+	// phase is broadcast, rather than relayed to the next peer of the stem
+	fluffProbability = 0.1
+	// minEmbargo and maxEmbargo bound the random time after which a stem tx is
+	// broadcast, if it was not seen broadcast by another node in the meantime
+	minEmbargo = 10 * time.Second
+	maxEmbargo = 30 * time.Second
+	// epochDuration is the interval at which a new stem successor is picked
+	epochDuration = 10 * time.Minute
+)
+
+// Dandelion is a processing unit which relays the txs in their stem phase. Each
+// stem tx is sent to a single peer, the stem successor, which is picked at random
+// for each epoch. At each hop, the tx is broadcast instead with a small
+// probability, so that the node broadcasting it is unlikely to be its origin.
+// As a fail-safe, each node broadcasts the stem txs it did not see broadcast
+// after a random embargo period.
+// It should be shared between all peers.
+type Dandelion struct {
+	lock      sync.Mutex
+	publisher wire.EventPublisher
+	// the peers which relay stem txs, by outgoing message queue
+	peers     []chan<- *bytes.Buffer
+	successor chan<- *bytes.Buffer
+	epochEnd  time.Time
+	// the peers which relayed the stem txs to us, by hex encoded tx hash
+	sources map[string]chan<- *bytes.Buffer
+	// the embargo timers of the stem txs, by hex encoded tx hash
+	embargoes map[string]*time.Timer
+}
+
+// NewDandelion returns an initialized Dandelion, subscribed to topics.Stem for
+// the stem txs verified by the mempool, and to topics.Inv for the txs broadcast.
+func NewDandelion(broker wire.EventBroker) *Dandelion {
+	d := &Dandelion{
+		publisher: broker,
+		sources:   make(map[string]chan<- *bytes.Buffer),
+		embargoes: make(map[string]*time.Timer),
+	}
+
+	broker.SubscribeCallback(string(topics.Stem), d.onStem)
+	broker.SubscribeCallback(string(topics.Inv), d.onInv)
+	return d
+}
+
+// AddPeer adds the peer with the given outgoing message queue to the stem
+// successor candidates, if it relays stem txs.
+func (d *Dandelion) AddPeer(responseChan chan<- *bytes.Buffer, features protocol.Features) {
+	if !features.Has(protocol.FeatureDandelion) {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.peers = append(d.peers, responseChan)
+}
+
+// RemovePeer removes the peer with the given outgoing message queue from the
+// stem successor candidates, once it is disconnected.
+func (d *Dandelion) RemovePeer(responseChan chan<- *bytes.Buffer) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.peers = removePeer(d.peers, responseChan)
+	if d.successor == responseChan {
+		d.successor = nil
+	}
+}
+
+// Received records that the peer with the given outgoing message queue relayed
+// the tx with the given hash to us in its stem phase, so that it is not sent
+// back to it.
+func (d *Dandelion) Received(hash []byte, responseChan chan<- *bytes.Buffer) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.sources[hex.EncodeToString(hash)] = responseChan
+}
+
+func (d *Dandelion) onStem(m *bytes.Buffer) error {
+	raw := append([]byte(nil), m.Bytes()...)
+	txs, err := transactions.FromReader(m, 1)
+	if err != nil {
+		return err
+	}
+
+	hash, err := txs[0].CalculateHash()
+	if err != nil {
+		return err
+	}
+
+	key := hex.EncodeToString(hash)
+	d.lock.Lock()
+	if _, ok := d.embargoes[key]; !ok {
+		d.embargoes[key] = time.AfterFunc(randomEmbargo(), func() {
+			d.fluff(hash, raw)
+		})
+	}
+
+	// our own txs are always relayed along the stem
+	source, relayed := d.sources[key]
+	var successor chan<- *bytes.Buffer
+	if !relayed || rand.Float64() >= fluffProbability {
+		successor = d.stemSuccessor(source, time.Now())
+	}
+	d.lock.Unlock()
+
+	if successor != nil && sendStemTx(successor, raw) {
+		return nil
+	}
+
+	d.fluff(hash, raw)
+	return nil
+}
+
+// onInv ends the embargo of the stem txs which are broadcast
+func (d *Dandelion) onInv(m *bytes.Buffer) error {
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(m); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, item := range inv.InvList {
+		if item.Type == peermsg.InvTypeMempoolTx {
+			d.forget(hex.EncodeToString(item.Hash))
+		}
+	}
+
+	return nil
+}
+
+// fluff ends the stem phase of a tx, by handing it to the mempool as a regular
+// tx, which is then advertised to all peers
+func (d *Dandelion) fluff(hash []byte, raw []byte) {
+	d.lock.Lock()
+	d.forget(hex.EncodeToString(hash))
+	d.lock.Unlock()
+
+	d.publisher.Publish(string(topics.Tx), bytes.NewBuffer(raw))
+}
+
+// forget the embargo and the source of a stem tx. Must be called with the lock
+// held.
+func (d *Dandelion) forget(key string) {
+	if timer, ok := d.embargoes[key]; ok {
+		timer.Stop()
+		delete(d.embargoes, key)
+	}
+
+	delete(d.sources, key)
+}
+
+// stemSuccessor returns the peer to relay a stem tx to, or nil if there is no
+// peer other than its source. Must be called with the lock held.
+func (d *Dandelion) stemSuccessor(source chan<- *bytes.Buffer, now time.Time) chan<- *bytes.Buffer {
+	if d.successor == nil || now.After(d.epochEnd) {
+		d.successor = nil
+		if len(d.peers) > 0 {
+			d.successor = d.peers[rand.Intn(len(d.peers))]
+			d.epochEnd = now.Add(epochDuration)
+		}
+	}
+
+	if d.successor != source {
+		return d.successor
+	}
+
+	// the tx is never sent back to the peer it came from
+	for _, p := range d.peers {
+		if p != source {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// sendStemTx puts a StemTx message on the outgoing queue of a peer. It returns
+// false if the queue is full.
+func sendStemTx(responseChan chan<- *bytes.Buffer, raw []byte) bool {
+	buf, err := wire.AddTopic(bytes.NewBuffer(raw), topics.StemTx)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "dandelion",
+			"error":   err,
+		}).Errorln("could not marshal stem tx")
+		return false
+	}
+
+	select {
+	case responseChan <- buf:
+		return true
+	default:
+		return false
+	}
+}
+
+func randomEmbargo() time.Duration {
+	return minEmbargo + time.Duration(rand.Int63n(int64(maxEmbargo-minEmbargo)))
+}
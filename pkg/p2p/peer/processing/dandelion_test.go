@@ -0,0 +1,109 @@
+package processing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// Stem txs should be relayed to a single peer, and broadcast when there is no
+// peer to relay them to, or when they are not seen broadcast in time.
+func TestDandelion(t *testing.T) {
+	minEmbargo = 100 * time.Millisecond
+	maxEmbargo = 200 * time.Millisecond
+	bus := wire.NewEventBus()
+	d := NewDandelion(bus)
+	txChan := make(chan *bytes.Buffer, 1)
+	bus.Subscribe(string(topics.Tx), txChan)
+
+	// only the peers relaying stem txs are stem successors
+	successor := make(chan *bytes.Buffer, 1)
+	d.AddPeer(successor, protocol.FeatureDandelion)
+	d.AddPeer(make(chan *bytes.Buffer, 1), 0)
+
+	// our own tx is relayed along the stem
+	own := stemTx(t)
+	bus.Publish(string(topics.Stem), bytes.NewBuffer(own))
+	select {
+	case m := <-successor:
+		topic, err := topics.Extract(m)
+		assert.NoError(t, err)
+		assert.Equal(t, topics.StemTx, topic)
+		assert.Equal(t, own, m.Bytes())
+	case <-time.After(time.Second):
+		t.Fatal("stem tx was not relayed")
+	}
+
+	// it is broadcast once the embargo is over
+	select {
+	case m := <-txChan:
+		assert.Equal(t, own, m.Bytes())
+	case <-time.After(time.Second):
+		t.Fatal("stem tx was not broadcast after the embargo")
+	}
+
+	// a stem tx is not sent back to the peer it came from
+	relayed := stemTx(t)
+	d.Received(txHash(t, relayed), successor)
+	bus.Publish(string(topics.Stem), bytes.NewBuffer(relayed))
+	select {
+	case m := <-txChan:
+		assert.Equal(t, relayed, m.Bytes())
+	case <-time.After(time.Second):
+		t.Fatal("stem tx was not broadcast")
+	}
+
+	assert.Empty(t, successor)
+}
+
+// The embargo of a stem tx should end once it is seen broadcast.
+func TestDandelionEmbargo(t *testing.T) {
+	minEmbargo = 100 * time.Millisecond
+	maxEmbargo = 200 * time.Millisecond
+	bus := wire.NewEventBus()
+	d := NewDandelion(bus)
+	txChan := make(chan *bytes.Buffer, 1)
+	bus.Subscribe(string(topics.Tx), txChan)
+	d.AddPeer(make(chan *bytes.Buffer, 1), protocol.FeatureDandelion)
+
+	tx := stemTx(t)
+	bus.Publish(string(topics.Stem), bytes.NewBuffer(tx))
+
+	inv := &peermsg.Inv{}
+	inv.AddItem(peermsg.InvTypeMempoolTx, txHash(t, tx))
+	buf := new(bytes.Buffer)
+	assert.NoError(t, inv.Encode(buf))
+	bus.Publish(string(topics.Inv), buf)
+
+	select {
+	case <-txChan:
+		t.Fatal("stem tx was broadcast twice")
+	case <-time.After(2 * maxEmbargo):
+	}
+}
+
+func stemTx(t *testing.T) []byte {
+	R := make([]byte, 32)
+	_, err := rand.Read(R)
+	assert.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, transactions.NewStandard(0, 100, R).Encode(buf))
+	return buf.Bytes()
+}
+
+func txHash(t *testing.T, raw []byte) []byte {
+	txs, err := transactions.FromReader(bytes.NewReader(raw), 1)
+	assert.NoError(t, err)
+	hash, err := txs[0].CalculateHash()
+	assert.NoError(t, err)
+	return hash
+}
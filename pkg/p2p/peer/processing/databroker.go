@@ -2,6 +2,7 @@ package processing
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
@@ -31,19 +32,21 @@ func NewDataBroker(db database.DB, rpcBus *wire.RPCBus, responseChan chan<- *byt
 }
 
 // SendItems takes a GetData message from the wire, and iterates through the list,
-// sending back each item's complete data to the requesting peer.
+// sending back each item's complete data to the requesting peer. The items we do
+// not have are listed in a NotFound message, so that the peer can ask someone else.
 func (d *DataBroker) SendItems(m *bytes.Buffer) error {
 	msg := &peermsg.Inv{}
 	if err := msg.Decode(m); err != nil {
 		return err
 	}
 
+	notFound := &peermsg.Inv{}
 	for _, obj := range msg.InvList {
 
 		var buf *bytes.Buffer
 		switch obj.Type {
-		case peermsg.InvTypeBlock:
-			// Fetch block from local state. It must be available
+		case peermsg.InvTypeBlock, peermsg.InvTypeCompactBlock:
+			// Fetch block from local state
 			var b *block.Block
 			err := d.db.View(func(t database.Transaction) error {
 				var err error
@@ -51,12 +54,23 @@ func (d *DataBroker) SendItems(m *bytes.Buffer) error {
 				return err
 			})
 
+			if err == database.ErrBlockNotFound {
+				notFound.AddItem(obj.Type, obj.Hash)
+				continue
+			}
+
 			if err != nil {
 				return err
 			}
 
-			// Send the block data back to the initiator node as topics.Block msg
-			buf, err = marshalBlock(b)
+			// Send the block data back to the initiator node as topics.Block msg,
+			// or as topics.CmpctBlock if it has the txs in its mempool
+			if obj.Type == peermsg.InvTypeCompactBlock {
+				buf, err = marshalCompactBlock(b)
+			} else {
+				buf, err = marshalBlock(b)
+			}
+
 			if err != nil {
 				return err
 			}
@@ -80,7 +94,10 @@ func (d *DataBroker) SendItems(m *bytes.Buffer) error {
 			//
 			// - The node has restarted and lost this Tx
 			// - The node has recently accepted a block that includes this Tx
-			// No action to run in these cases.
+			// The peer is told, so that it can ask someone else.
+			if len(txs) == 0 {
+				notFound.AddItem(obj.Type, obj.Hash)
+			}
 		}
 
 		if buf != nil {
@@ -88,6 +105,69 @@ func (d *DataBroker) SendItems(m *bytes.Buffer) error {
 		}
 	}
 
+	if notFound.InvList != nil {
+		buf, err := marshalNotFound(notFound)
+		if err != nil {
+			return err
+		}
+
+		d.responseChan <- buf
+	}
+
+	return nil
+}
+
+// SendBlockTxs takes a GetBlockTxn message from the wire, and sends the requested
+// txs of a block back to the requesting peer.
+func (d *DataBroker) SendBlockTxs(m *bytes.Buffer) error {
+	msg := &peermsg.GetBlockTxn{}
+	if err := msg.Decode(m); err != nil {
+		return err
+	}
+
+	var b *block.Block
+	err := d.db.View(func(t database.Transaction) error {
+		var err error
+		b, err = t.FetchBlock(msg.BlockHash)
+		return err
+	})
+
+	if err == database.ErrBlockNotFound {
+		notFound := &peermsg.Inv{}
+		notFound.AddItem(peermsg.InvTypeBlock, msg.BlockHash)
+		buf, err := marshalNotFound(notFound)
+		if err != nil {
+			return err
+		}
+
+		d.responseChan <- buf
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	blockTxn := &peermsg.BlockTxn{BlockHash: msg.BlockHash}
+	for _, index := range msg.Indexes {
+		if int(index) >= len(b.Txs) {
+			return errors.New("requested tx index out of range")
+		}
+
+		blockTxn.Txs = append(blockTxn.Txs, b.Txs[index])
+	}
+
+	buf := new(bytes.Buffer)
+	if err := blockTxn.Encode(buf); err != nil {
+		return err
+	}
+
+	resp, err := wire.AddTopic(buf, topics.BlockTxn)
+	if err != nil {
+		return err
+	}
+
+	d.responseChan <- resp
 	return nil
 }
 
@@ -143,6 +223,29 @@ func marshalBlock(b *block.Block) (*bytes.Buffer, error) {
 	return wire.AddTopic(buf, topics.Block)
 }
 
+func marshalCompactBlock(b *block.Block) (*bytes.Buffer, error) {
+	c, err := peermsg.NewCompactBlock(b)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := c.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	return wire.AddTopic(buf, topics.CmpctBlock)
+}
+
+func marshalNotFound(notFound *peermsg.Inv) (*bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	if err := notFound.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	return wire.AddTopic(buf, topics.NotFound)
+}
+
 func marshalTx(tx transactions.Transaction) (*bytes.Buffer, error) {
 	buf := new(bytes.Buffer)
 	if err := tx.Encode(buf); err != nil {
@@ -60,6 +60,41 @@ func TestSendData(t *testing.T) {
 	}
 }
 
+// The blocks we do not have should be listed in a NotFound message.
+func TestSendNotFound(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+	defer db.Close()
+
+	hashes, blocks := generateBlocks(t, 2)
+	if err := storeBlocks(db, blocks[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	responseChan := make(chan *bytes.Buffer, 100)
+	dataBroker := processing.NewDataBroker(db, nil, responseChan)
+	if err := dataBroker.SendItems(createGetDataBuffer(hashes...)); err != nil {
+		t.Fatal(err)
+	}
+
+	if topic := extractTopic(<-responseChan); topic != topics.Block {
+		t.Fatalf("unexpected topic %s, expected Block", topic)
+	}
+
+	buf := <-responseChan
+	if topic := extractTopic(buf); topic != topics.NotFound {
+		t.Fatalf("unexpected topic %s, expected NotFound", topic)
+	}
+
+	notFound := &peermsg.Inv{}
+	if err := notFound.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notFound.InvList) != 1 || !bytes.Equal(hashes[1], notFound.InvList[0].Hash) {
+		t.Fatal("NotFound does not list the missing block")
+	}
+}
+
 // TODO: probably specify somewhere a choice between block and tx type
 func createGetDataBuffer(hashes ...[]byte) *bytes.Buffer {
 	inv := &peermsg.Inv{}
@@ -18,21 +18,28 @@ type DataRequestor struct {
 	db           database.DB
 	responseChan chan<- *bytes.Buffer
 	rpcBus       *wire.RPCBus
+	requests     *RequestTracker
 }
 
-// NewDataRequestor returns an initialized DataRequestor.
-func NewDataRequestor(db database.DB, rpcBus *wire.RPCBus, responseChan chan<- *bytes.Buffer) *DataRequestor {
+// NewDataRequestor returns an initialized DataRequestor. The passed RequestTracker
+// should be shared between all instances of the DataRequestor.
+func NewDataRequestor(db database.DB, rpcBus *wire.RPCBus, responseChan chan<- *bytes.Buffer, requests *RequestTracker) *DataRequestor {
 	return &DataRequestor{
 		db:           db,
 		responseChan: responseChan,
 		rpcBus:       rpcBus,
+		requests:     requests,
 	}
 }
 
 // AskForMissingItems takes an inventory message, checks it for any items that the node
 // is missing, puts these items in a GetData wire message, and sends it off to the peer's
-// outgoing message queue, requesting the items in full.
-func (d *DataRequestor) RequestMissingItems(m *bytes.Buffer) error {
+// outgoing message queue, requesting the items in full. Blocks are only requested
+// if withBlocks is set, as not every peer serves full blocks, and are requested as
+// compact blocks if compact is set. The items already
+// requested from another peer are not requested again, unless that peer fails to
+// deliver them.
+func (d *DataRequestor) RequestMissingItems(m *bytes.Buffer, withBlocks, compact bool) error {
 	msg := &peermsg.Inv{}
 	if err := msg.Decode(m); err != nil {
 		return err
@@ -43,6 +50,9 @@ func (d *DataRequestor) RequestMissingItems(m *bytes.Buffer) error {
 
 		switch obj.Type {
 		case peermsg.InvTypeBlock:
+			if !withBlocks {
+				continue
+			}
 
 			// Check if local blockchain state does include this block hash ...
 			err := d.db.View(func(t database.Transaction) error {
@@ -78,7 +88,22 @@ func (d *DataRequestor) RequestMissingItems(m *bytes.Buffer) error {
 	}
 
 	// If we found any items to be missing, we request them from the peer who
-	// advertised them.
+	// advertised them, unless they are requested from another peer already.
+	missing := getData.InvList
+	getData.InvList = nil
+	for _, item := range missing {
+		if !d.requests.Advertised(item, d.responseChan) {
+			continue
+		}
+
+		// the retries to other peers are for the full block
+		if item.Type == peermsg.InvTypeBlock && compact {
+			item.Type = peermsg.InvTypeCompactBlock
+		}
+
+		getData.InvList = append(getData.InvList, item)
+	}
+
 	if getData.InvList != nil {
 		// we've got objects that are missing, then packet and request them
 		buf, err := marshalGetData(getData)
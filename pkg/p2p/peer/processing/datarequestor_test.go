@@ -17,7 +17,7 @@ func TestRequestData(t *testing.T) {
 	defer db.Close()
 
 	responseChan := make(chan *bytes.Buffer, 100)
-	dataRequestor := processing.NewDataRequestor(db, nil, responseChan)
+	dataRequestor := processing.NewDataRequestor(db, nil, responseChan, processing.NewRequestTracker())
 
 	// Send topics.Inv
 	hash, buf, err := createInvBuffer()
@@ -25,7 +25,7 @@ func TestRequestData(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := dataRequestor.RequestMissingItems(buf); err != nil {
+	if err := dataRequestor.RequestMissingItems(buf, true, false); err != nil {
 		t.Fatal(err)
 	}
 
@@ -52,6 +52,28 @@ func TestRequestData(t *testing.T) {
 	}
 }
 
+// Blocks should not be requested from peers which do not serve them.
+func TestRequestDataWithoutBlocks(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+	defer db.Close()
+
+	responseChan := make(chan *bytes.Buffer, 100)
+	dataRequestor := processing.NewDataRequestor(db, nil, responseChan, processing.NewRequestTracker())
+
+	_, buf, err := createInvBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dataRequestor.RequestMissingItems(buf, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responseChan) != 0 {
+		t.Fatal("blocks were requested from a peer not serving them")
+	}
+}
+
 func createInvBuffer() ([]byte, *bytes.Buffer, error) {
 	msg := &peermsg.Inv{}
 	hash, _ := crypto.RandEntropy(32)
@@ -3,55 +3,212 @@ package processing
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/golang/snappy"
 )
 
 const (
 	MaxFrameSize = uint64(250000)
+
+	// FrameOverhead is the room left in a frame for the authentication tag
+	// of an encrypted payload
+	FrameOverhead = uint64(16)
+
+	// CompressionThreshold is the payload size from which the frames are
+	// compressed, when the format supports it
+	CompressionThreshold = 1024
+
+	// compressedFlag is the bit of the length prefix of a FrameV2 frame which
+	// is set when its payload is compressed
+	compressedFlag = uint64(1) << 63
+)
+
+// FrameVersion identifies the format of the frames exchanged with a peer. The
+// handshake is always performed with FrameV0, after which both ends switch to
+// the highest version they support.
+type FrameVersion uint8
+
+const (
+	// FrameV0 is a uint64 length, followed by the payload
+	FrameV0 FrameVersion = iota
+	// FrameV1 is a uint64 length, followed by a CRC-32C checksum of the
+	// payload, and the payload
+	FrameV1
+	// FrameV2 is a FrameV1 whose payload may be compressed with snappy, as
+	// flagged by the highest bit of the length
+	FrameV2
+
+	// CurrentFrameVersion is the highest version supported by this node
+	CurrentFrameVersion = FrameV2
 )
 
-//BuildFrame builds a length-prefixing wire message frame
+// ErrChecksumMismatch is returned by ReadFrameVersion when the payload of a
+// frame does not match its checksum. The frame is consumed entirely, so that
+// the next frame can still be read.
+var ErrChecksumMismatch = errors.New("frame checksum mismatch")
+
+// ErrDecompression is returned when the payload of a frame can not be
+// decompressed, or would exceed MaxFrameSize once decompressed. The frame is
+// consumed entirely, so that the next frame can still be read.
+var ErrDecompression = errors.New("invalid compressed frame")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteFrame builds a FrameV0 length-prefixing wire message frame
 func WriteFrame(buf *bytes.Buffer) (*bytes.Buffer, error) {
 	if uint64(buf.Len()) > MaxFrameSize {
 		return nil, fmt.Errorf("message size exceeds MaxFrameSize (%d)", MaxFrameSize)
 	}
 
+	return WriteFrameVersion(buf, FrameV0)
+}
+
+// WriteFrameVersion builds a wire message frame in the given format. The
+// payload may exceed MaxFrameSize by FrameOverhead, to fit an encrypted
+// message.
+func WriteFrameVersion(buf *bytes.Buffer, v FrameVersion) (*bytes.Buffer, error) {
+	return WriteFrameFlags(buf, v, false)
+}
+
+// WriteFrameFlags builds a wire message frame in the given format, flagging
+// its payload as compressed if needed. Only FrameV2 supports the flag.
+func WriteFrameFlags(buf *bytes.Buffer, v FrameVersion, compressed bool) (*bytes.Buffer, error) {
+	if uint64(buf.Len()) > MaxFrameSize+FrameOverhead {
+		return nil, fmt.Errorf("message size exceeds MaxFrameSize (%d)", MaxFrameSize)
+	}
+
+	size := uint64(buf.Len())
+	if compressed {
+		if v < FrameV2 {
+			return nil, fmt.Errorf("frame version %d does not support compression", v)
+		}
+
+		size |= compressedFlag
+	}
+
 	msg := new(bytes.Buffer)
 	// Append prefix(header)
-	if err := encoding.WriteUint64(msg, binary.LittleEndian, uint64(buf.Len())); err != nil {
+	if err := encoding.WriteUint64(msg, binary.LittleEndian, size); err != nil {
 		return nil, err
 	}
 
+	if v >= FrameV1 {
+		if err := encoding.WriteUint32(msg, binary.LittleEndian, crc32.Checksum(buf.Bytes(), crcTable)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Append payload
 	_, err := msg.ReadFrom(buf)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Append Checksum
-
 	return msg, nil
 }
 
+// ReadFrame reads a FrameV0 frame from r, and returns its payload
 func ReadFrame(r io.Reader) ([]byte, error) {
+	return ReadFrameVersion(r, FrameV0)
+}
+
+// ReadFrameVersion reads a frame in the given format from r, and returns its
+// payload. The checksum, if any, is verified before returning, and the payload
+// is decompressed if needed.
+func ReadFrameVersion(r io.Reader, v FrameVersion) ([]byte, error) {
+	buf, compressed, err := ReadFrameFlags(r, v)
+	if err != nil || !compressed {
+		return buf, err
+	}
+
+	return Decompress(buf)
+}
+
+// ReadFrameFlags reads a frame in the given format from r, and returns its
+// payload as is, along with whether it is compressed. The checksum, if any, is
+// verified before returning.
+func ReadFrameFlags(r io.Reader, v FrameVersion) ([]byte, bool, error) {
 	sizeBytes := make([]byte, 8)
 	if _, err := io.ReadFull(r, sizeBytes); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	size := binary.LittleEndian.Uint64(sizeBytes)
-	if size > MaxFrameSize {
-		return nil, fmt.Errorf("message size exceeds MaxFrameSize (%d), %d", MaxFrameSize, size)
+	compressed := false
+	if v >= FrameV2 && size&compressedFlag != 0 {
+		compressed = true
+		size &^= compressedFlag
+	}
+
+	if size > MaxFrameSize+FrameOverhead {
+		return nil, false, fmt.Errorf("message size exceeds MaxFrameSize (%d), %d", MaxFrameSize, size)
+	}
+
+	var checksum uint32
+	if v >= FrameV1 {
+		if err := encoding.ReadUint32(r, binary.LittleEndian, &checksum); err != nil {
+			return nil, false, err
+		}
 	}
 
 	buf := make([]byte, int(size))
 	_, err := io.ReadFull(r, buf)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	if v >= FrameV1 && crc32.Checksum(buf, crcTable) != checksum {
+		return nil, false, ErrChecksumMismatch
+	}
+
+	return buf, compressed, nil
+}
+
+// Compress compresses a payload with snappy, if it is large enough for it to
+// be worth it. It returns false if the payload is left as is.
+func Compress(payload []byte) ([]byte, bool) {
+	if len(payload) < CompressionThreshold {
+		return payload, false
+	}
+
+	compressed := snappy.Encode(nil, payload)
+	if len(compressed) >= len(payload) {
+		return payload, false
+	}
+
+	return compressed, true
+}
+
+// Decompress decompresses a payload compressed by Compress. The size of the
+// decompressed payload is checked against MaxFrameSize before decompressing
+// it, so that a small frame can not expand into an arbitrarily large one.
+func Decompress(compressed []byte) ([]byte, error) {
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil || uint64(size) > MaxFrameSize {
+		return nil, ErrDecompression
+	}
+
+	payload, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, ErrDecompression
+	}
+
+	return payload, nil
+}
+
+// FramePayload returns the payload of a FrameV0 frame, as built by WriteFrame.
+// Messages which are framed once for all the peers, such as the gossip stream,
+// are unwrapped this way before being framed in the format negotiated with
+// each peer.
+func FramePayload(frame []byte) ([]byte, error) {
+	if len(frame) < 8 || binary.LittleEndian.Uint64(frame[:8]) != uint64(len(frame)-8) {
+		return nil, errors.New("invalid frame")
 	}
 
-	return buf, nil
+	return frame[8:], nil
 }
@@ -0,0 +1,85 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that FrameV1 frames survive a round trip, and that corruption of the
+// payload is detected without losing track of the following frame.
+func TestFrameChecksum(t *testing.T) {
+	payload := []byte("pippo")
+	frame, err := processing.WriteFrameVersion(bytes.NewBuffer(payload), processing.FrameV1)
+	assert.NoError(t, err)
+	assert.Equal(t, 8+4+len(payload), frame.Len())
+
+	corrupted := append([]byte{}, frame.Bytes()...)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	stream := bytes.NewBuffer(corrupted)
+	stream.Write(frame.Bytes())
+
+	_, err = processing.ReadFrameVersion(stream, processing.FrameV1)
+	assert.Equal(t, processing.ErrChecksumMismatch, err)
+
+	read, err := processing.ReadFrameVersion(stream, processing.FrameV1)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, read)
+}
+
+// Test the extraction of the payload of legacy frames.
+func TestFramePayload(t *testing.T) {
+	payload := []byte("pippo")
+	frame, err := processing.WriteFrame(bytes.NewBuffer(payload))
+	assert.NoError(t, err)
+
+	read, err := processing.FramePayload(frame.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, payload, read)
+
+	_, err = processing.FramePayload(frame.Bytes()[:10])
+	assert.Error(t, err)
+}
+
+// Test that large FrameV2 payloads are compressed, and that a compressed frame
+// can not expand beyond MaxFrameSize.
+func TestFrameCompression(t *testing.T) {
+	payload := bytes.Repeat([]byte("pippo"), 1000)
+	compressed, ok := processing.Compress(payload)
+	assert.True(t, ok)
+
+	frame, err := processing.WriteFrameFlags(bytes.NewBuffer(compressed), processing.FrameV2, true)
+	assert.NoError(t, err)
+	assert.True(t, frame.Len() < len(payload))
+
+	read, err := processing.ReadFrameVersion(bytes.NewBuffer(frame.Bytes()), processing.FrameV2)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, read)
+
+	// small payloads are left as is
+	_, ok = processing.Compress([]byte("pippo"))
+	assert.False(t, ok)
+
+	// and so are the frames of the older versions
+	_, err = processing.WriteFrameFlags(bytes.NewBuffer(compressed), processing.FrameV1, true)
+	assert.Error(t, err)
+
+	// a bomb is rejected, without losing track of the following frame
+	bomb := snappy.Encode(nil, make([]byte, processing.MaxFrameSize+1))
+	bombFrame, err := processing.WriteFrameFlags(bytes.NewBuffer(bomb), processing.FrameV2, true)
+	assert.NoError(t, err)
+
+	stream := bytes.NewBuffer(bombFrame.Bytes())
+	stream.Write(frame.Bytes())
+
+	_, err = processing.ReadFrameVersion(stream, processing.FrameV2)
+	assert.Equal(t, processing.ErrDecompression, err)
+
+	read, err = processing.ReadFrameVersion(stream, processing.FrameV2)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, read)
+}
@@ -0,0 +1,237 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// trickleInterval is the interval at which the pending tx inventories are
+	// advertised to the peers
+	trickleInterval = 500 * time.Millisecond
+	// maxKnownInventory is the amount of item hashes remembered per peer
+	maxKnownInventory = 5000
+	// maxTrickleItems is the maximum amount of inventories advertised to a
+	// peer at once
+	maxTrickleItems = 1000
+	// maxPendingInventory is the amount of inventories waiting for the next
+	// trickle per peer. The oldest ones are dropped first, as the peer is the
+	// most likely to know of them already.
+	maxPendingInventory = 10 * maxTrickleItems
+)
+
+// knownInventory is a bounded set of item hashes. The oldest hashes are
+// forgotten first.
+type knownInventory struct {
+	set   map[string]struct{}
+	order []string
+}
+
+func newKnownInventory() *knownInventory {
+	return &knownInventory{set: make(map[string]struct{})}
+}
+
+func (k *knownInventory) add(hash []byte) {
+	key := hex.EncodeToString(hash)
+	if _, ok := k.set[key]; ok {
+		return
+	}
+
+	if len(k.order) >= maxKnownInventory {
+		delete(k.set, k.order[0])
+		k.order = k.order[1:]
+	}
+
+	k.set[key] = struct{}{}
+	k.order = append(k.order, key)
+}
+
+func (k *knownInventory) has(hash []byte) bool {
+	_, ok := k.set[hex.EncodeToString(hash)]
+	return ok
+}
+
+type invPeer struct {
+	known *knownInventory
+	// inventories waiting for the next trickle
+	pending []peermsg.InvVect
+}
+
+// queue items for the next trickle
+func (p *invPeer) queue(items ...peermsg.InvVect) {
+	p.pending = append(p.pending, items...)
+	if excess := len(p.pending) - maxPendingInventory; excess > 0 {
+		p.pending = p.pending[excess:]
+	}
+}
+
+// InvRelay is a processing unit which advertises the items published on
+// topics.Inv to the peers. It remembers the items each peer knows about, so that
+// an item is never advertised back to a peer which has it. Blocks are advertised
+// straight away, while txs are batched and trickled at regular intervals.
+// It should be shared between all peers.
+type InvRelay struct {
+	lock sync.Mutex
+	// the peers, by outgoing message queue
+	peers map[chan<- *bytes.Buffer]*invPeer
+	// the pending inventories are being trickled
+	trickling bool
+}
+
+// NewInvRelay returns an initialized InvRelay, subscribed to topics.Inv.
+func NewInvRelay(subscriber wire.EventSubscriber) *InvRelay {
+	r := &InvRelay{peers: make(map[chan<- *bytes.Buffer]*invPeer)}
+	subscriber.SubscribeCallback(string(topics.Inv), r.onInv)
+	return r
+}
+
+// AddPeer starts advertising inventories to the peer with the given outgoing
+// message queue.
+func (r *InvRelay) AddPeer(responseChan chan<- *bytes.Buffer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.peers[responseChan] = &invPeer{known: newKnownInventory()}
+}
+
+// RemovePeer stops advertising inventories to the peer with the given outgoing
+// message queue, once it is disconnected.
+func (r *InvRelay) RemovePeer(responseChan chan<- *bytes.Buffer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.peers, responseChan)
+}
+
+// Known records that the peer with the given outgoing message queue knows
+// about the item with the given hash, because it sent or advertised it to us.
+func (r *InvRelay) Known(responseChan chan<- *bytes.Buffer, hash []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if p, ok := r.peers[responseChan]; ok {
+		p.known.add(hash)
+	}
+}
+
+// KnownInv records that the peer with the given outgoing message queue knows
+// about the items listed in an inventory message it advertised.
+func (r *InvRelay) KnownInv(responseChan chan<- *bytes.Buffer, m *bytes.Buffer) error {
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(bytes.NewReader(m.Bytes())); err != nil {
+		return err
+	}
+
+	for _, item := range inv.InvList {
+		r.Known(responseChan, item.Hash)
+	}
+
+	return nil
+}
+
+func (r *InvRelay) onInv(m *bytes.Buffer) error {
+	inv := &peermsg.Inv{}
+	if err := inv.Decode(m); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for responseChan, p := range r.peers {
+		blocks := &peermsg.Inv{}
+		for _, item := range inv.InvList {
+			if p.known.has(item.Hash) {
+				continue
+			}
+
+			p.known.add(item.Hash)
+			if item.Type == peermsg.InvTypeBlock {
+				blocks.AddItem(item.Type, item.Hash)
+				continue
+			}
+
+			p.queue(item)
+		}
+
+		// blocks are advertised straight away, as they matter most to the
+		// consensus, or with the next trickle if the queue of the peer is full
+		if blocks.InvList != nil && !sendInv(responseChan, blocks) {
+			p.queue(blocks.InvList...)
+		}
+
+		if len(p.pending) > 0 && !r.trickling {
+			r.trickling = true
+			go r.trickle()
+		}
+	}
+
+	return nil
+}
+
+// trickle advertises the pending inventories to each peer, in a single Inv
+// message, at regular intervals, until there are none left
+func (r *InvRelay) trickle() {
+	ticker := time.NewTicker(trickleInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !r.flush() {
+			return
+		}
+	}
+}
+
+// flush advertises the pending inventories. It returns false once there are
+// none left, and the trickle can stop.
+func (r *InvRelay) flush() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	left := false
+	for responseChan, p := range r.peers {
+		if len(p.pending) == 0 {
+			continue
+		}
+
+		n := len(p.pending)
+		if n > maxTrickleItems {
+			n = maxTrickleItems
+		}
+
+		// the inventories are kept for the next trickle, if the queue of the
+		// peer is full
+		if sendInv(responseChan, &peermsg.Inv{InvList: p.pending[:n:n]}) {
+			p.pending = p.pending[n:]
+		}
+
+		left = left || len(p.pending) > 0
+	}
+
+	if !left {
+		r.trickling = false
+	}
+
+	return left
+}
+
+// sendInv puts an Inv message on the outgoing queue of a peer. It returns false
+// if the queue is full.
+func sendInv(responseChan chan<- *bytes.Buffer, inv *peermsg.Inv) bool {
+	buf, err := marshalInv(inv)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"process": "inv relay",
+			"error":   err,
+		}).Errorln("could not marshal inventory")
+		return true
+	}
+
+	select {
+	case responseChan <- buf:
+		return true
+	default:
+		return false
+	}
+}
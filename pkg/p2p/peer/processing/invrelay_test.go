@@ -0,0 +1,76 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// An item should not be advertised to the peer which sent it to us, and the txs
+// should be advertised in a single batch.
+func TestRelayInventory(t *testing.T) {
+	bus := wire.NewEventBus()
+	relay := processing.NewInvRelay(bus)
+	a := make(chan *bytes.Buffer, 10)
+	b := make(chan *bytes.Buffer, 10)
+	relay.AddPeer(a)
+	relay.AddPeer(b)
+
+	tx1, tx2 := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	blk := bytes.Repeat([]byte{3}, 32)
+
+	// a relayed tx1 and the block to us
+	relay.Known(a, tx1)
+	relay.Known(a, blk)
+
+	publishInv(t, bus, peermsg.InvTypeBlock, blk)
+	publishInv(t, bus, peermsg.InvTypeMempoolTx, tx1, tx2)
+
+	// the block is advertised to b straight away
+	assert.Equal(t, [][]byte{blk}, receiveInv(t, b))
+
+	// the txs are trickled
+	assert.Equal(t, [][]byte{tx1, tx2}, receiveInv(t, b))
+	assert.Equal(t, [][]byte{tx2}, receiveInv(t, a))
+
+	// nothing is advertised twice
+	publishInv(t, bus, peermsg.InvTypeMempoolTx, tx2)
+	time.Sleep(time.Second)
+	assert.Equal(t, 0, len(a))
+	assert.Equal(t, 0, len(b))
+}
+
+func publishInv(t *testing.T, bus *wire.EventBus, invType peermsg.InvType, hashes ...[]byte) {
+	inv := &peermsg.Inv{}
+	for _, hash := range hashes {
+		inv.AddItem(invType, hash)
+	}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, inv.Encode(buf))
+	bus.Publish(string(topics.Inv), buf)
+}
+
+func receiveInv(t *testing.T, peer chan *bytes.Buffer) [][]byte {
+	select {
+	case msg := <-peer:
+		assert.Equal(t, topics.Inv, extractTopic(msg))
+		inv := &peermsg.Inv{}
+		assert.NoError(t, inv.Decode(msg))
+		hashes := make([][]byte, 0, len(inv.InvList))
+		for _, item := range inv.InvList {
+			hashes = append(hashes, item.Hash)
+		}
+
+		return hashes
+	case <-time.After(2 * time.Second):
+		t.Fatal("no inventory received")
+		return nil
+	}
+}
@@ -0,0 +1,317 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math/bits"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/crypto/hash"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
+)
+
+// idBits is the size in bits of the node IDs, and the amount of buckets of the
+// routing table
+const idBits = 256
+
+var (
+	// DefaultRedundancy is the amount of peers a message is delegated to, per
+	// bucket
+	DefaultRedundancy = 3
+	// heightTTL is the amount of time the height of a message received through
+	// the overlay is remembered, waiting for it to be republished
+	heightTTL = 2 * time.Minute
+)
+
+type kadPeer struct {
+	id           []byte
+	responseChan chan<- *bytes.Buffer
+}
+
+type relayHeight struct {
+	height uint8
+	expiry time.Time
+}
+
+// Kadcast is a Broadcaster which propagates the gossip over a structured
+// overlay. The peers are sorted into buckets by the XOR distance between their
+// node ID and ours, as in Kademlia. A message is delegated to a few peers of
+// each bucket, each of them being responsible for propagating it to the part of
+// the ID space covered by the buckets below that one. This way, every node
+// receives a message a few times, rather than once from each of its peers.
+// The peers which do not support the overlay are flooded, and so are all the
+// peers when a bucket known to be populated has no peer left to delegate to.
+// It should be shared between all peers.
+type Kadcast struct {
+	lock       sync.Mutex
+	localID    []byte
+	redundancy int
+	buckets    [idBits][]kadPeer
+	// the buckets which held a peer at some point
+	populated [idBits]bool
+	// the peers which do not support the overlay
+	flooded map[chan<- *bytes.Buffer]struct{}
+	// the heights of the messages received through the overlay, by hex
+	// encoded message hash
+	heights map[string]relayHeight
+}
+
+// NewKadcast returns an initialized Kadcast, streaming topics.Gossip. identity
+// is the identity key of the node. The gossip frames are expected to be
+// prefixed with the network magic.
+func NewKadcast(subscriber wire.EventSubscriber, identity []byte, redundancy int) (*Kadcast, error) {
+	localID, err := hash.Sha3256(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if redundancy <= 0 {
+		redundancy = DefaultRedundancy
+	}
+
+	k := &Kadcast{
+		localID:    localID,
+		redundancy: redundancy,
+		flooded:    make(map[chan<- *bytes.Buffer]struct{}),
+		heights:    make(map[string]relayHeight),
+	}
+
+	subscriber.SubscribeStream(string(topics.Gossip), k)
+	return k, nil
+}
+
+// AddPeer adds a peer to the routing table, or to the flooded peers if it does
+// not support the overlay.
+func (k *Kadcast) AddPeer(id []byte, features protocol.Features, conn io.WriteCloser, responseChan chan<- *bytes.Buffer) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if len(id) == 0 || !features.Has(protocol.FeatureKadcast) {
+		k.flooded[responseChan] = struct{}{}
+		return
+	}
+
+	nodeID, err := hash.Sha3256(id)
+	if err != nil {
+		k.flooded[responseChan] = struct{}{}
+		return
+	}
+
+	i, ok := bucket(k.localID, nodeID)
+	if !ok {
+		// a node connected to itself
+		return
+	}
+
+	k.buckets[i] = append(k.buckets[i], kadPeer{nodeID, responseChan})
+	k.populated[i] = true
+}
+
+// Wants returns true if the node with the given identity key would fill a
+// bucket which has less peers than the redundancy. It is used to pick the
+// nodes to connect to.
+func (k *Kadcast) Wants(id []byte) bool {
+	nodeID, err := hash.Sha3256(id)
+	if err != nil {
+		return false
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	i, ok := bucket(k.localID, nodeID)
+	return ok && len(k.buckets[i]) < k.redundancy
+}
+
+// Features returns protocol.FeatureKadcast, as the peers are sent Kadcast
+// messages.
+func (k *Kadcast) Features() protocol.Features {
+	return protocol.FeatureKadcast
+}
+
+// RemovePeer removes a disconnected peer from the routing table.
+func (k *Kadcast) RemovePeer(responseChan chan<- *bytes.Buffer) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	delete(k.flooded, responseChan)
+	for i, peers := range k.buckets {
+		for j, p := range peers {
+			if p.responseChan == responseChan {
+				k.buckets[i] = append(peers[:j], peers[j+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Relayed remembers the height at which a message was received, so that it is
+// only propagated to the buckets below that height once it is republished.
+func (k *Kadcast) Relayed(m []byte, height uint8) {
+	key, err := messageKey(m)
+	if err != nil {
+		return
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	now := time.Now()
+	for key, h := range k.heights {
+		if now.After(h.expiry) {
+			delete(k.heights, key)
+		}
+	}
+
+	k.heights[key] = relayHeight{height, now.Add(heightTTL)}
+}
+
+// Write takes a gossip frame from the stream, and propagates it.
+func (k *Kadcast) Write(frame []byte) (int, error) {
+	payload, err := FramePayload(frame)
+	if err != nil || len(payload) < 4 {
+		log.WithField("process", "kadcast").Warnln("invalid gossip frame")
+		return len(frame), nil
+	}
+
+	// strip the magic, which the peers prepend themselves
+	if err := k.broadcast(payload[4:]); err != nil {
+		log.WithFields(log.Fields{
+			"process": "kadcast",
+			"error":   err,
+		}).Warnln("could not broadcast message")
+	}
+
+	return len(frame), nil
+}
+
+// Close is a no-op, as the stream lives as long as the node.
+func (k *Kadcast) Close() error {
+	return nil
+}
+
+// broadcast delegates a message to the peers of the buckets below its height.
+// The messages which did not come through the overlay originate from us, and
+// are delegated in every bucket.
+// If one of these buckets lost all of its peers, its part of the ID space can
+// not be reached through the overlay until the bucket is refilled, so the
+// message is flooded to all the peers, which propagate it from their own
+// routing tables. The buckets which never held a peer are assumed empty, or
+// else every message would be flooded.
+func (k *Kadcast) broadcast(m []byte) error {
+	key, err := messageKey(m)
+	if err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	height := idBits
+	if h, ok := k.heights[key]; ok {
+		height = int(h.height)
+		delete(k.heights, key)
+	}
+
+	orphaned := false
+	for i := 0; i < height; i++ {
+		if len(k.buckets[i]) == 0 {
+			orphaned = orphaned || k.populated[i]
+			continue
+		}
+
+		msg, err := marshalKadcast(uint8(i), m)
+		if err != nil {
+			return err
+		}
+
+		for _, j := range rand.Perm(len(k.buckets[i]))[:min(k.redundancy, len(k.buckets[i]))] {
+			sendGossip(k.buckets[i][j].responseChan, msg)
+		}
+	}
+
+	for responseChan := range k.flooded {
+		sendGossip(responseChan, m)
+	}
+
+	if orphaned {
+		for _, peers := range k.buckets {
+			for _, p := range peers {
+				sendGossip(p.responseChan, m)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadKadcastHeight reads the height off a Kadcast message, leaving the topic
+// and the payload of the propagated message in m.
+func ReadKadcastHeight(m *bytes.Buffer) (uint8, error) {
+	var height uint8
+	err := encoding.ReadUint8(m, &height)
+	return height, err
+}
+
+func marshalKadcast(height uint8, m []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint8(buf, height); err != nil {
+		return nil, err
+	}
+
+	if _, err := buf.Write(m); err != nil {
+		return nil, err
+	}
+
+	msg, err := wire.AddTopic(buf, topics.Kadcast)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+// sendGossip puts a copy of a message on the outgoing queue of a peer, as the
+// queue drains the buffers it is handed. The message is dropped if the queue
+// is full, as the other peers propagate it too.
+func sendGossip(responseChan chan<- *bytes.Buffer, m []byte) {
+	msg := make([]byte, len(m))
+	copy(msg, m)
+	select {
+	case responseChan <- bytes.NewBuffer(msg):
+	default:
+		log.WithField("process", "kadcast").Debugln("outgoing queue full, dropping gossip message")
+	}
+}
+
+// bucket returns the index of the bucket of a node, which is the position of the
+// highest bit set in the XOR distance between the IDs. It returns false if the
+// IDs are equal.
+func bucket(localID, nodeID []byte) (int, bool) {
+	for i := range localID {
+		if d := localID[i] ^ nodeID[i]; d != 0 {
+			return idBits - 1 - i*8 - bits.LeadingZeros8(d), true
+		}
+	}
+
+	return 0, false
+}
+
+func messageKey(m []byte) (string, error) {
+	h, err := hash.Xxhash(m)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h), nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
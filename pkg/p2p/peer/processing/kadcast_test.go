@@ -0,0 +1,127 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+// The gossip should be delegated to the peers of each bucket below the height
+// of the message, and flooded to the peers which do not support the overlay.
+func TestKadcast(t *testing.T) {
+	bus := wire.NewEventBus()
+	bus.RegisterPreprocessor(string(topics.Gossip), processing.NewGossip(protocol.TestNet))
+	k, err := processing.NewKadcast(bus, randomIdentity(t), 100)
+	assert.NoError(t, err)
+
+	peers := make([]chan *bytes.Buffer, 20)
+	for i := range peers {
+		peers[i] = make(chan *bytes.Buffer, 10)
+		k.AddPeer(randomIdentity(t), protocol.FeatureKadcast, nil, peers[i])
+	}
+
+	flooded := make(chan *bytes.Buffer, 10)
+	k.AddPeer(randomIdentity(t), 0, nil, flooded)
+
+	// our own message is delegated in every bucket, to all of its peers given
+	// the redundancy
+	msg, err := wire.AddTopic(bytes.NewBufferString("agreement"), topics.Agreement)
+	assert.NoError(t, err)
+	bus.Stream(string(topics.Gossip), bytes.NewBuffer(msg.Bytes()))
+
+	assert.Equal(t, msg.Bytes(), receive(t, flooded).Bytes())
+	for _, p := range peers {
+		m := receive(t, p)
+		topic, err := topics.Extract(m)
+		assert.NoError(t, err)
+		assert.Equal(t, topics.Kadcast, topic)
+
+		_, err = processing.ReadKadcastHeight(m)
+		assert.NoError(t, err)
+		assert.Equal(t, msg.Bytes(), m.Bytes())
+	}
+
+	// a message received at height 0 is not delegated any further
+	relayed, err := wire.AddTopic(bytes.NewBufferString("reduction"), topics.Reduction)
+	assert.NoError(t, err)
+	k.Relayed(relayed.Bytes(), 0)
+	bus.Stream(string(topics.Gossip), bytes.NewBuffer(relayed.Bytes()))
+
+	assert.Equal(t, relayed.Bytes(), receive(t, flooded).Bytes())
+	for _, p := range peers {
+		assert.Empty(t, p)
+	}
+
+	// disconnected peers are forgotten
+	k.RemovePeer(flooded)
+	bus.Stream(string(topics.Gossip), bytes.NewBuffer(msg.Bytes()))
+	receive(t, peers[0])
+	assert.Empty(t, flooded)
+}
+
+// Once a bucket lost its peers, the gossip should be flooded to the remaining
+// ones, until the bucket is refilled.
+func TestKadcastOrphanedBucket(t *testing.T) {
+	bus := wire.NewEventBus()
+	bus.RegisterPreprocessor(string(topics.Gossip), processing.NewGossip(protocol.TestNet))
+	k, err := processing.NewKadcast(bus, randomIdentity(t), 1)
+	assert.NoError(t, err)
+
+	gone := randomIdentity(t)
+	goneChan := make(chan *bytes.Buffer, 10)
+	k.AddPeer(gone, protocol.FeatureKadcast, nil, goneChan)
+	assert.False(t, k.Wants(gone))
+
+	// the other peer fills another bucket
+	id := randomIdentity(t)
+	for !k.Wants(id) {
+		id = randomIdentity(t)
+	}
+
+	peer := make(chan *bytes.Buffer, 10)
+	k.AddPeer(id, protocol.FeatureKadcast, nil, peer)
+	assert.False(t, k.Wants(id))
+
+	msg, err := wire.AddTopic(bytes.NewBufferString("agreement"), topics.Agreement)
+	assert.NoError(t, err)
+	bus.Stream(string(topics.Gossip), bytes.NewBuffer(msg.Bytes()))
+
+	topic, err := topics.Extract(receive(t, peer))
+	assert.NoError(t, err)
+	assert.Equal(t, topics.Kadcast, topic)
+	receive(t, goneChan)
+	assert.Empty(t, peer)
+
+	k.RemovePeer(goneChan)
+	assert.True(t, k.Wants(gone))
+	bus.Stream(string(topics.Gossip), bytes.NewBuffer(msg.Bytes()))
+
+	topic, err = topics.Extract(receive(t, peer))
+	assert.NoError(t, err)
+	assert.Equal(t, topics.Kadcast, topic)
+	assert.Equal(t, msg.Bytes(), receive(t, peer).Bytes())
+}
+
+func receive(t *testing.T, c chan *bytes.Buffer) *bytes.Buffer {
+	select {
+	case m := <-c:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+
+	return nil
+}
+
+func randomIdentity(t *testing.T) []byte {
+	pub, _, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	return pub
+}
@@ -0,0 +1,186 @@
+package processing
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
+)
+
+// MessageClass is the priority class of an outgoing message. The messages of
+// the lower classes are written first.
+type MessageClass uint8
+
+// A list of the message classes, by decreasing priority
+const (
+	// ClassConsensus is the class of the consensus messages, which time out
+	// if they are delayed, and of the keep-alive messages
+	ClassConsensus MessageClass = iota
+	// ClassBlocks is the class of the blocks and certificates
+	ClassBlocks
+	// ClassTxs is the class of the tx relay
+	ClassTxs
+	// ClassBulk is the class of the chain sync and of the other messages
+	ClassBulk
+
+	classCount
+)
+
+type dropPolicy uint8
+
+const (
+	// dropOldest makes room for a new message by dropping the oldest one,
+	// for the messages which lose their value with time
+	dropOldest dropPolicy = iota
+	// dropNewest drops the new message, for the messages which are
+	// requested again when they are missing
+	dropNewest
+)
+
+var (
+	// outboundCapacity is the amount of messages queued per class
+	outboundCapacity = [classCount]int{1000, 500, 1000, 1000}
+	outboundPolicy   = [classCount]dropPolicy{dropOldest, dropNewest, dropNewest, dropNewest}
+)
+
+type classQueue struct {
+	msgs    []*bytes.Buffer
+	dropped uint64
+}
+
+// OutboundQueue is the queue of the messages waiting to be written to a peer.
+// Each class of messages has its own bounded queue, and the messages of the
+// highest priority class are written first. It accepts the messages with their
+// topic, or the gossip frames through Write.
+type OutboundQueue struct {
+	lock    sync.Mutex
+	classes [classCount]classQueue
+	// signaled when a message is queued
+	ready chan struct{}
+}
+
+// NewOutboundQueue returns an initialized OutboundQueue.
+func NewOutboundQueue() *OutboundQueue {
+	return &OutboundQueue{ready: make(chan struct{}, 1)}
+}
+
+// Push queues a message, starting with its topic. It returns false if a message
+// was dropped, because the queue of its class is full.
+func (q *OutboundQueue) Push(m *bytes.Buffer) bool {
+	class := Classify(m.Bytes())
+
+	q.lock.Lock()
+	c := &q.classes[class]
+	full := len(c.msgs) >= outboundCapacity[class]
+	if full {
+		c.dropped++
+		if outboundPolicy[class] == dropNewest {
+			q.lock.Unlock()
+			logDrop(class)
+			return false
+		}
+
+		c.msgs[0] = nil
+		c.msgs = c.msgs[1:]
+	}
+
+	c.msgs = append(c.msgs, m)
+	q.lock.Unlock()
+
+	if full {
+		logDrop(class)
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+
+	return !full
+}
+
+// Pop returns the oldest message of the highest priority class, or nil if the
+// queue is empty.
+func (q *OutboundQueue) Pop() *bytes.Buffer {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i := range q.classes {
+		c := &q.classes[i]
+		if len(c.msgs) > 0 {
+			m := c.msgs[0]
+			c.msgs[0] = nil
+			c.msgs = c.msgs[1:]
+			return m
+		}
+	}
+
+	return nil
+}
+
+// Ready returns a channel which is signaled when a message is queued.
+func (q *OutboundQueue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Dropped returns the amount of messages of a class dropped so far.
+func (q *OutboundQueue) Dropped(class MessageClass) uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.classes[class].dropped
+}
+
+// Write queues a gossip frame, as built by the Gossip preprocessor.
+func (q *OutboundQueue) Write(frame []byte) (int, error) {
+	payload, err := FramePayload(frame)
+	if err != nil || len(payload) < 4 {
+		log.WithField("process", "outbound queue").Warnln("invalid gossip frame")
+		return len(frame), nil
+	}
+
+	// strip the magic, which is prepended again when the message is written
+	q.Push(bytes.NewBuffer(payload[4:]))
+	return len(frame), nil
+}
+
+// Close is a no-op, as the queue lives as long as the connection.
+func (q *OutboundQueue) Close() error {
+	return nil
+}
+
+// Classify returns the class of a message, starting with its topic. The
+// messages propagated through Kadcast are classified by the topic they carry.
+func Classify(m []byte) MessageClass {
+	topic := peekTopic(m)
+	if topic == topics.Kadcast && len(m) > topics.Size {
+		// skip the height
+		topic = peekTopic(m[topics.Size+1:])
+	}
+
+	switch topic {
+	case topics.Candidate, topics.Score, topics.Reduction, topics.Agreement,
+		topics.Ping, topics.Pong:
+		return ClassConsensus
+	case topics.Block, topics.CmpctBlock, topics.GetBlockTxn, topics.BlockTxn,
+		topics.Certificate:
+		return ClassBlocks
+	case topics.Tx, topics.StemTx, topics.Inv, topics.GetData, topics.NotFound,
+		topics.MemPool:
+		return ClassTxs
+	}
+
+	return ClassBulk
+}
+
+func peekTopic(m []byte) topics.Topic {
+	var topicBytes [topics.Size]byte
+	copy(topicBytes[:], m)
+	return topics.ByteArrayToTopic(topicBytes)
+}
+
+func logDrop(class MessageClass) {
+	log.WithFields(log.Fields{
+		"process": "outbound queue",
+		"class":   class,
+	}).Debugln("queue full, dropping message")
+}
@@ -0,0 +1,87 @@
+package processing_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// The messages should be written by order of priority, regardless of the order
+// in which they are queued.
+func TestOutboundPriority(t *testing.T) {
+	q := processing.NewOutboundQueue()
+	q.Push(outboundMsg(t, topics.GetBlocks, 0))
+	q.Push(outboundMsg(t, topics.Tx, 0))
+	q.Push(outboundMsg(t, topics.Block, 0))
+
+	// the gossip frames are queued too
+	frame, err := processing.NewGossip(protocol.TestNet).Process(outboundMsg(t, topics.Reduction, 0))
+	assert.NoError(t, err)
+	_, err = q.Write(frame.Bytes())
+	assert.NoError(t, err)
+
+	// the messages propagated through the overlay keep their priority
+	kadcast := outboundMsg(t, topics.Kadcast, 0)
+	kadcast.Truncate(topics.Size)
+	kadcast.WriteByte(5)
+	kadcast.Write(outboundMsg(t, topics.Agreement, 0).Bytes())
+	q.Push(kadcast)
+
+	for _, expected := range []topics.Topic{topics.Reduction, topics.Kadcast, topics.Block, topics.Tx, topics.GetBlocks} {
+		m := q.Pop()
+		if m == nil {
+			t.Fatalf("expected a %s message", expected)
+		}
+
+		topic, err := topics.Extract(m)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, topic)
+	}
+
+	assert.Nil(t, q.Pop())
+}
+
+// Each class should drop messages according to its own policy once full.
+func TestOutboundDropPolicy(t *testing.T) {
+	q := processing.NewOutboundQueue()
+
+	// the oldest votes are dropped
+	for i := 0; i <= 1000; i++ {
+		q.Push(outboundMsg(t, topics.Reduction, uint32(i)))
+	}
+
+	assert.Equal(t, uint64(1), q.Dropped(processing.ClassConsensus))
+	assert.Equal(t, uint32(1), outboundIndex(t, q.Pop()))
+
+	// the new blocks are dropped
+	for i := 0; i < 500; i++ {
+		assert.True(t, q.Push(outboundMsg(t, topics.Block, uint32(i))))
+	}
+
+	assert.False(t, q.Push(outboundMsg(t, topics.Block, 500)))
+	assert.Equal(t, uint64(1), q.Dropped(processing.ClassBlocks))
+	assert.Equal(t, uint64(0), q.Dropped(processing.ClassTxs))
+}
+
+func outboundMsg(t *testing.T, topic topics.Topic, i uint32) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, binary.Write(buf, binary.LittleEndian, i))
+	m, err := wire.AddTopic(buf, topic)
+	assert.NoError(t, err)
+	return m
+}
+
+func outboundIndex(t *testing.T, m *bytes.Buffer) uint32 {
+	_, err := topics.Extract(m)
+	assert.NoError(t, err)
+
+	var i uint32
+	assert.NoError(t, binary.Read(m, binary.LittleEndian, &i))
+	return i
+}
@@ -0,0 +1,159 @@
+package processing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+var (
+	// pingInterval is the time between two pings. It should stay well below
+	// the read timeout of the peer, so that idle connections are kept alive.
+	pingInterval = 20 * time.Second
+	// pongTimeout is the time after which a peer which did not answer a ping
+	// is considered unresponsive
+	pongTimeout = 30 * time.Second
+)
+
+// ErrPongTimeout is returned by Pinger.Run when a ping is left unanswered
+var ErrPongTimeout = errors.New("peer did not respond to ping")
+
+// Pinger is a processing unit which keeps the connection with a peer alive,
+// by periodically sending a Ping message carrying a random nonce. The peer
+// answers with a Pong carrying the same nonce, which gives us the round trip
+// time of the connection.
+type Pinger struct {
+	lock         sync.Mutex
+	responseChan chan<- *bytes.Buffer
+	onRTT        func(time.Duration)
+
+	nonce uint64
+	// sent is the time the pending ping was sent, zero if no ping is pending
+	sent time.Time
+	rtt  time.Duration
+}
+
+// NewPinger returns an initialized Pinger. onRTT is called, if not nil, every
+// time a round trip time is measured.
+func NewPinger(responseChan chan<- *bytes.Buffer, onRTT func(time.Duration)) *Pinger {
+	return &Pinger{
+		responseChan: responseChan,
+		onRTT:        onRTT,
+	}
+}
+
+// Run sends a ping every pingInterval, until quitChan is closed. It returns
+// ErrPongTimeout if the peer does not answer a ping in time.
+func (p *Pinger) Run(quitChan <-chan struct{}) error {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if p.timedOut(time.Now()) {
+				return ErrPongTimeout
+			}
+
+			if err := p.SendPing(); err != nil {
+				return err
+			}
+		case <-quitChan:
+			return nil
+		}
+	}
+}
+
+func (p *Pinger) timedOut(now time.Time) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return !p.sent.IsZero() && now.Sub(p.sent) > pongTimeout
+}
+
+// SendPing sends a Ping with a new nonce to the peer, unless a ping is already
+// pending.
+func (p *Pinger) SendPing() error {
+	p.lock.Lock()
+	if !p.sent.IsZero() {
+		p.lock.Unlock()
+		return nil
+	}
+
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		p.lock.Unlock()
+		return err
+	}
+
+	nonce := binary.LittleEndian.Uint64(b[:])
+	p.nonce = nonce
+	p.sent = time.Now()
+	p.lock.Unlock()
+
+	return p.send(topics.Ping, nonce)
+}
+
+// OnPing answers a Ping message with a Pong carrying the same nonce
+func (p *Pinger) OnPing(m *bytes.Buffer) error {
+	var nonce uint64
+	if err := encoding.ReadUint64(m, binary.LittleEndian, &nonce); err != nil {
+		return err
+	}
+
+	return p.send(topics.Pong, nonce)
+}
+
+// OnPong measures the round trip time of the pending ping. Pongs which do not
+// match the pending ping are rejected.
+func (p *Pinger) OnPong(m *bytes.Buffer) error {
+	var nonce uint64
+	if err := encoding.ReadUint64(m, binary.LittleEndian, &nonce); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	if p.sent.IsZero() || nonce != p.nonce {
+		p.lock.Unlock()
+		return errors.New("unexpected pong nonce")
+	}
+
+	p.rtt = time.Since(p.sent)
+	p.sent = time.Time{}
+	rtt := p.rtt
+	p.lock.Unlock()
+
+	if p.onRTT != nil {
+		p.onRTT(rtt)
+	}
+
+	return nil
+}
+
+// RTT returns the last measured round trip time, or zero if none was measured yet
+func (p *Pinger) RTT() time.Duration {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.rtt
+}
+
+func (p *Pinger) send(topic topics.Topic, nonce uint64) error {
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint64(buf, binary.LittleEndian, nonce); err != nil {
+		return err
+	}
+
+	msg, err := wire.AddTopic(buf, topic)
+	if err != nil {
+		return err
+	}
+
+	p.responseChan <- msg
+	return nil
+}
@@ -0,0 +1,46 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test a full ping/pong exchange between two Pingers, and the measurement of
+// the round trip time.
+func TestPingPong(t *testing.T) {
+	aChan := make(chan *bytes.Buffer, 1)
+	bChan := make(chan *bytes.Buffer, 1)
+
+	var measured time.Duration
+	a := processing.NewPinger(aChan, func(rtt time.Duration) { measured = rtt })
+	b := processing.NewPinger(bChan, nil)
+
+	assert.NoError(t, a.SendPing())
+	ping := <-aChan
+	assert.Equal(t, topics.Ping, extractTopic(ping))
+
+	// a second ping is not sent while the first is pending
+	assert.NoError(t, a.SendPing())
+	assert.Equal(t, 0, len(aChan))
+
+	assert.NoError(t, b.OnPing(ping))
+	pong := <-bChan
+	assert.Equal(t, topics.Pong, extractTopic(pong))
+
+	// a pong with the wrong nonce is rejected
+	wrong := bytes.NewBuffer(make([]byte, 8))
+	assert.Error(t, a.OnPong(wrong))
+
+	replay := append([]byte{}, pong.Bytes()...)
+	assert.NoError(t, a.OnPong(pong))
+	assert.NotEqual(t, time.Duration(0), a.RTT())
+	assert.Equal(t, a.RTT(), measured)
+
+	// the pong can not be replayed
+	assert.Error(t, a.OnPong(bytes.NewBuffer(replay)))
+}
@@ -0,0 +1,190 @@
+package processing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+// burstSeconds is the amount of traffic a token bucket holds, in seconds of
+// its rate
+const burstSeconds = 5
+
+// DefaultTopicRates are the amount of messages per second accepted from each
+// peer, by topic. The blocks, which we request, are not limited. The rates of
+// the other replies, like the headers, leave room for the bursts of a sync.
+var DefaultTopicRates = map[topics.Topic]float64{
+	topics.Tx:          50,
+	topics.StemTx:      50,
+	topics.Inv:         20,
+	topics.GetData:     20,
+	topics.NotFound:    20,
+	topics.Reject:      50,
+	topics.Candidate:   10,
+	topics.CmpctBlock:  10,
+	topics.GetBlockTxn: 10,
+	topics.BlockTxn:    10,
+	topics.Score:       100,
+	topics.Reduction:   200,
+	topics.Agreement:   200,
+	topics.Kadcast:     200,
+	topics.MemPool:     1,
+	topics.GetBlocks:   1,
+	topics.GetHeaders:  1,
+	topics.Headers:     10,
+	topics.GetAddr:     1,
+	topics.Addr:        1,
+	topics.Ping:        1,
+	topics.Pong:        1,
+}
+
+// TokenBucket is a rate limiter which allows bursts of up to burstSeconds of
+// traffic. A nil TokenBucket does not limit anything.
+type TokenBucket struct {
+	lock     sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket refilled at the given rate per
+// second, or nil if rate is not positive.
+func NewTokenBucket(rate float64) *TokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	capacity := rate * burstSeconds
+	return &TokenBucket{rate: rate, capacity: capacity, tokens: capacity, last: time.Now()}
+}
+
+// Take n tokens from the bucket, if there are enough.
+func (b *TokenBucket) Take(n float64, now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill(now)
+	if b.tokens < n {
+		return false
+	}
+
+	b.tokens -= n
+	return true
+}
+
+// Reserve takes n tokens from the bucket, even if there are not enough, and
+// returns the time to wait until the bucket is out of debt.
+func (b *TokenBucket) Reserve(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill(now)
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+
+	b.last = now
+}
+
+// Limits holds the limits of the traffic received from the peers. The global
+// bandwidth limit is shared by all peers, and the other limits apply to each
+// peer separately.
+// It should be shared between all peers.
+type Limits struct {
+	global        *TokenBucket
+	peerBandwidth float64
+	topicRates    map[topics.Topic]float64
+}
+
+// NewLimits returns the limits of the traffic received from the peers. The
+// bandwidths are in bytes per second, and the topic rates in messages per
+// second. A bandwidth which is not positive is not limited, and neither are
+// the topics without a rate.
+func NewLimits(globalBandwidth, peerBandwidth float64, topicRates map[topics.Topic]float64) *Limits {
+	return &Limits{
+		global:        NewTokenBucket(globalBandwidth),
+		peerBandwidth: peerBandwidth,
+		topicRates:    topicRates,
+	}
+}
+
+// RateLimiter is a processing unit which enforces the Limits on the traffic
+// received from a peer.
+type RateLimiter struct {
+	global    *TokenBucket
+	bandwidth *TokenBucket
+	topics    map[topics.Topic]*TokenBucket
+
+	lock sync.Mutex
+	// the time each topic was last exceeded, since it was within its rate
+	exceeded map[topics.Topic]time.Time
+}
+
+// NewRateLimiter returns a RateLimiter enforcing the given limits on a peer.
+func NewRateLimiter(limits *Limits) *RateLimiter {
+	l := &RateLimiter{
+		global:    limits.global,
+		bandwidth: NewTokenBucket(limits.peerBandwidth),
+		topics:    make(map[topics.Topic]*TokenBucket, len(limits.topicRates)),
+		exceeded:  make(map[topics.Topic]time.Time),
+	}
+
+	for topic, rate := range limits.topicRates {
+		if bucket := NewTokenBucket(rate); bucket != nil {
+			l.topics[topic] = bucket
+		}
+	}
+
+	return l
+}
+
+// Throttle accounts for n bytes received from the peer, and returns the time
+// to wait before reading from it again, to stay within the bandwidth limits.
+func (l *RateLimiter) Throttle(n int, now time.Time) time.Duration {
+	wait := l.bandwidth.Reserve(float64(n), now)
+	if global := l.global.Reserve(float64(n), now); global > wait {
+		wait = global
+	}
+
+	return wait
+}
+
+// Allow returns false if the peer exceeded the rate of the given topic, in
+// which case the message should be dropped.
+func (l *RateLimiter) Allow(topic topics.Topic, now time.Time) bool {
+	return l.topics[topic].Take(1, now)
+}
+
+// Exceeded records that a message of the given topic was dropped. It returns
+// true for the first message dropped in every burstSeconds, so that the peer
+// is penalized once per overflow rather than for every message.
+func (l *RateLimiter) Exceeded(topic topics.Topic, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if last, ok := l.exceeded[topic]; ok && now.Sub(last) < burstSeconds*time.Second {
+		return false
+	}
+
+	l.exceeded[topic] = now
+	return true
+}
@@ -0,0 +1,70 @@
+package processing_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// A peer should be allowed bursts of messages of a topic, and then be held to
+// the rate of the topic.
+func TestTopicRate(t *testing.T) {
+	limits := processing.NewLimits(0, 0, map[topics.Topic]float64{topics.Tx: 10})
+	l := processing.NewRateLimiter(limits)
+	now := time.Now()
+
+	// the bucket holds 5 seconds of messages
+	for i := 0; i < 50; i++ {
+		assert.True(t, l.Allow(topics.Tx, now))
+	}
+
+	assert.False(t, l.Allow(topics.Tx, now))
+
+	// it is refilled over time
+	now = now.Add(100 * time.Millisecond)
+	assert.True(t, l.Allow(topics.Tx, now))
+	assert.False(t, l.Allow(topics.Tx, now))
+
+	// the topics without a rate are not limited
+	for i := 0; i < 1000; i++ {
+		assert.True(t, l.Allow(topics.Block, now))
+	}
+
+	// and each peer has its own buckets
+	assert.True(t, processing.NewRateLimiter(limits).Allow(topics.Tx, now))
+}
+
+// A peer exceeding the rate of a topic should be penalized once per overflow,
+// rather than for every message dropped.
+func TestExceeded(t *testing.T) {
+	l := processing.NewRateLimiter(processing.NewLimits(0, 0, nil))
+	now := time.Now()
+
+	assert.True(t, l.Exceeded(topics.Tx, now))
+	assert.False(t, l.Exceeded(topics.Tx, now.Add(time.Second)))
+	assert.True(t, l.Exceeded(topics.Inv, now))
+	assert.True(t, l.Exceeded(topics.Tx, now.Add(10*time.Second)))
+}
+
+// The reads should be held off once a peer, or all of them together, exceed
+// their bandwidth.
+func TestBandwidth(t *testing.T) {
+	limits := processing.NewLimits(2000, 1000, nil)
+	first := processing.NewRateLimiter(limits)
+	second := processing.NewRateLimiter(limits)
+	now := time.Now()
+
+	// each peer can burst 5000 bytes
+	assert.Equal(t, time.Duration(0), first.Throttle(5000, now))
+	assert.Equal(t, time.Second, first.Throttle(1000, now))
+
+	// which leaves 4000 bytes to the other peers
+	assert.Equal(t, time.Duration(0), second.Throttle(4000, now))
+	assert.Equal(t, 500*time.Millisecond, second.Throttle(1000, now))
+
+	// no bandwidth means no limit
+	assert.Equal(t, time.Duration(0), processing.NewRateLimiter(processing.NewLimits(0, 0, nil)).Throttle(1<<20, now))
+}
@@ -0,0 +1,195 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
+)
+
+// originTTL is the amount of time we remember which peer relayed an item.
+var originTTL = 2 * time.Minute
+
+type origin struct {
+	responseChan chan<- *bytes.Buffer
+	expiry       time.Time
+	// onInvalid is called, if not nil, when the item is rejected as invalid
+	onInvalid func(reason string)
+}
+
+// Rejector is a processing unit which keeps track of the peers that relayed txs and
+// blocks to us. When the mempool or the chain reject one of these items, a
+// topics.Reject event is published on the event bus, which the Rejector forwards
+// to the outgoing message queue of the originating peer.
+// It should be shared between all peers.
+type Rejector struct {
+	lock    sync.Mutex
+	origins map[string]origin
+	// the tracked hashes, in order of expiry
+	expiries []expiry
+}
+
+type expiry struct {
+	key string
+	at  time.Time
+}
+
+// NewRejector returns an initialized Rejector, subscribed to topics.Reject.
+func NewRejector(subscriber wire.EventSubscriber) *Rejector {
+	r := &Rejector{origins: make(map[string]origin)}
+	subscriber.SubscribeCallback(string(topics.Reject), r.onReject)
+	return r
+}
+
+// Track remembers that the item with the given hash was relayed by the peer
+// with the given outgoing message queue. The first peer to relay an item stays
+// its origin, so that a peer relaying a bogus item under the hash of another
+// can not shift the rejection onto the peers relaying the genuine one.
+func (r *Rejector) Track(hash []byte, responseChan chan<- *bytes.Buffer) {
+	r.track(hash, responseChan, nil)
+}
+
+func (r *Rejector) track(hash []byte, responseChan chan<- *bytes.Buffer, onInvalid func(string)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	r.expire(now)
+
+	key := string(hash)
+	if _, ok := r.origins[key]; ok {
+		return
+	}
+
+	r.origins[key] = origin{responseChan, now.Add(originTTL), onInvalid}
+	r.expiries = append(r.expiries, expiry{key, now.Add(originTTL)})
+}
+
+// expire forgets the origins past their expiry. As they all live for
+// originTTL, the oldest ones expire first. Must be called with the lock held.
+func (r *Rejector) expire(now time.Time) {
+	for len(r.expiries) > 0 && now.After(r.expiries[0].at) {
+		e := r.expiries[0]
+		r.expiries = r.expiries[1:]
+
+		// the item may have been rejected, and tracked again since
+		if o, ok := r.origins[e.key]; ok && !o.expiry.After(e.at) {
+			delete(r.origins, e.key)
+		}
+	}
+}
+
+func (r *Rejector) onReject(m *bytes.Buffer) error {
+	reject := &peermsg.Reject{}
+	if err := reject.Decode(m); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	o, ok := r.origins[string(reject.Hash)]
+	delete(r.origins, string(reject.Hash))
+	r.lock.Unlock()
+
+	// The item did not come from a peer
+	if !ok {
+		return nil
+	}
+
+	if reject.Code == peermsg.RejectInvalid && o.onInvalid != nil {
+		o.onInvalid(reject.Reason)
+	}
+
+	return SendReject(o.responseChan, reject)
+}
+
+// TxHash decodes a tx coming from the wire, and returns its hash, so that the
+// tx can be tracked with Track. If the tx can not be decoded, a Reject is sent
+// back straight away, and the decoding error is returned.
+func TxHash(m *bytes.Buffer, responseChan chan<- *bytes.Buffer) ([]byte, error) {
+	txs, err := transactions.FromReader(bytes.NewReader(m.Bytes()), 1)
+	if err != nil {
+		return nil, rejectMalformed(responseChan, topics.Tx, err)
+	}
+
+	hash, err := txs[0].CalculateHash()
+	if err != nil {
+		return nil, rejectMalformed(responseChan, topics.Tx, err)
+	}
+
+	return hash, nil
+}
+
+// TrackBlock decodes the header of a block coming from the wire, tracks it
+// for the peer that relayed it, and returns its hash. The hash is computed
+// from the header, as the one sent along with it is not trusted. If the header
+// can not be decoded, a Reject is sent back straight away, and the decoding
+// error is returned. onInvalid is called, if not nil, when the block is
+// rejected as invalid.
+func (r *Rejector) TrackBlock(m *bytes.Buffer, responseChan chan<- *bytes.Buffer, onInvalid func(reason string)) ([]byte, error) {
+	header := &block.Header{}
+	if err := header.Decode(bytes.NewReader(m.Bytes())); err != nil {
+		return nil, rejectMalformed(responseChan, topics.Block, err)
+	}
+
+	if err := header.SetHash(); err != nil {
+		return nil, rejectMalformed(responseChan, topics.Block, err)
+	}
+
+	r.track(header.Hash, responseChan, onInvalid)
+	return header.Hash, nil
+}
+
+func rejectMalformed(responseChan chan<- *bytes.Buffer, topic topics.Topic, err error) error {
+	if sendErr := SendReject(responseChan, peermsg.NewReject(topic, peermsg.RejectMalformed, err.Error(), nil)); sendErr != nil {
+		return sendErr
+	}
+
+	return err
+}
+
+// SendReject marshals a Reject message and puts it on the outgoing queue of a peer.
+// The message is dropped if the queue is full, as it is merely informative.
+func SendReject(responseChan chan<- *bytes.Buffer, reject *peermsg.Reject) error {
+	buf := new(bytes.Buffer)
+	if err := reject.Encode(buf); err != nil {
+		return err
+	}
+
+	msg, err := wire.AddTopic(buf, topics.Reject)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case responseChan <- msg:
+	default:
+		log.WithField("process", "rejector").Warnln("outgoing queue full, dropping reject message")
+	}
+
+	return nil
+}
+
+// LogReject decodes a Reject message received from a peer, and logs it.
+func LogReject(m *bytes.Buffer, peerInfo string) error {
+	reject := &peermsg.Reject{}
+	if err := reject.Decode(m); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"process": "peer",
+		"peer":    peerInfo,
+		"message": reject.Message,
+		"code":    reject.Code.String(),
+		"reason":  reject.Reason,
+		"hash":    hex.EncodeToString(reject.Hash),
+	}).Warnln("item rejected by peer")
+	return nil
+}
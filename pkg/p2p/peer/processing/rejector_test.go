@@ -0,0 +1,96 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/crypto"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that a rejection published on the event bus reaches the peer which
+// relayed the rejected item.
+func TestRejectToOrigin(t *testing.T) {
+	eb := wire.NewEventBus()
+	rejector := processing.NewRejector(eb)
+
+	responseChan := make(chan *bytes.Buffer, 10)
+	hash, _ := crypto.RandEntropy(32)
+	rejector.Track(hash, responseChan)
+
+	reject := peermsg.NewReject(topics.Tx, peermsg.RejectFeeTooLow, "fee too low", hash)
+	buf := new(bytes.Buffer)
+	if err := reject.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	eb.Publish(string(topics.Reject), buf)
+
+	response := <-responseChan
+	if topic := extractTopic(response); topic != topics.Reject {
+		t.Fatalf("unexpected topic %s, expected Reject", topic)
+	}
+
+	received := &peermsg.Reject{}
+	if err := received.Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, reject, received)
+
+	// A second rejection of the same item should not be relayed again
+	buf = new(bytes.Buffer)
+	_ = reject.Encode(buf)
+	eb.Publish(string(topics.Reject), buf)
+	assert.Equal(t, 0, len(responseChan))
+}
+
+// Test that only the peer which relayed a bogus block is penalized, when the
+// bogus block claims the hash of a genuine one relayed by another peer.
+func TestRejectBogusBlockOrigin(t *testing.T) {
+	honest := helper.RandomBlock(t, 1, 1)
+	assert.NoError(t, honest.SetHash())
+
+	// a different header, claiming the hash of the genuine block
+	forged := helper.RandomBlock(t, 1, 1)
+	forged.Header.Hash = honest.Header.Hash
+
+	// the genuine header, along with other txs
+	tampered := &block.Block{Header: honest.Header, Txs: helper.RandomSliceOfTxs(t, 1)}
+
+	for _, bogus := range []*block.Block{forged, tampered} {
+		eb := wire.NewEventBus()
+		rejector := processing.NewRejector(eb)
+		attackerChan := make(chan *bytes.Buffer, 10)
+		honestChan := make(chan *bytes.Buffer, 10)
+		var attackerPenalized, honestPenalized bool
+
+		// the attacker relays its block first
+		hash, err := rejector.TrackBlock(encodeBlock(t, bogus), attackerChan, func(string) { attackerPenalized = true })
+		assert.NoError(t, err)
+		_, err = rejector.TrackBlock(encodeBlock(t, honest), honestChan, func(string) { honestPenalized = true })
+		assert.NoError(t, err)
+
+		// the chain rejects the bogus block, under its actual hash
+		buf := new(bytes.Buffer)
+		assert.NoError(t, peermsg.NewReject(topics.Block, peermsg.RejectInvalid, "merkle root mismatch", hash).Encode(buf))
+		eb.Publish(string(topics.Reject), buf)
+
+		<-attackerChan
+		assert.True(t, attackerPenalized)
+		assert.False(t, honestPenalized)
+		assert.Empty(t, honestChan)
+	}
+}
+
+func encodeBlock(t *testing.T, blk *block.Block) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, blk.Encode(buf))
+	return buf
+}
@@ -0,0 +1,217 @@
+package processing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestTimeout is the time a peer has to deliver the items requested from it,
+// before they are requested from another peer.
+var requestTimeout = 30 * time.Second
+
+var errQueueFull = errors.New("outgoing queue of the peer is full")
+
+type pendingRequest struct {
+	item     peermsg.InvVect
+	peer     chan<- *bytes.Buffer
+	deadline time.Time
+	// the other peers which advertised the item, by order of advertisement
+	alternatives []chan<- *bytes.Buffer
+}
+
+// RequestTracker is a processing unit which keeps track of the items requested
+// from the peers with a GetData. When a peer answers with a NotFound, or does not
+// deliver an item in time, the item is requested from another peer which
+// advertised it.
+// It should be shared between all peers.
+type RequestTracker struct {
+	lock sync.Mutex
+	// outstanding requests, by hex encoded item hash
+	pending  map[string]*pendingRequest
+	watching bool
+}
+
+// NewRequestTracker returns an initialized RequestTracker.
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{pending: make(map[string]*pendingRequest)}
+}
+
+// Advertised records that the peer with the given outgoing message queue
+// advertised an item we are missing. It returns true if the item should be
+// requested from the peer, or false if it is requested from another peer
+// already, in which case the peer is remembered as an alternative.
+func (r *RequestTracker) Advertised(item peermsg.InvVect, peer chan<- *bytes.Buffer) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := hex.EncodeToString(item.Hash)
+	if req, ok := r.pending[key]; ok {
+		if req.peer != peer && !containsPeer(req.alternatives, peer) {
+			req.alternatives = append(req.alternatives, peer)
+		}
+
+		return false
+	}
+
+	r.pending[key] = &pendingRequest{
+		item:     item,
+		peer:     peer,
+		deadline: time.Now().Add(requestTimeout),
+	}
+
+	if !r.watching {
+		r.watching = true
+		go r.watch()
+	}
+
+	return true
+}
+
+// Requested returns true if the item with the given hash was requested from the
+// peer with the given outgoing message queue, and was not delivered yet.
+func (r *RequestTracker) Requested(hash []byte, peer chan<- *bytes.Buffer) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	req, ok := r.pending[hex.EncodeToString(hash)]
+	return ok && req.peer == peer
+}
+
+// Received marks the item with the given hash as delivered.
+func (r *RequestTracker) Received(hash []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.pending, hex.EncodeToString(hash))
+}
+
+// NotFound takes a NotFound message received from the peer with the given
+// outgoing message queue, and requests the listed items from other peers.
+func (r *RequestTracker) NotFound(m *bytes.Buffer, peer chan<- *bytes.Buffer) error {
+	msg := &peermsg.Inv{}
+	if err := msg.Decode(m); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, item := range msg.InvList {
+		req, ok := r.pending[hex.EncodeToString(item.Hash)]
+		if !ok || req.peer != peer {
+			continue
+		}
+
+		r.retry(req, time.Now())
+	}
+
+	return nil
+}
+
+// Forget the peer with the given outgoing message queue, once it is
+// disconnected. The items requested from it are requested from other peers.
+func (r *RequestTracker) Forget(peer chan<- *bytes.Buffer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	for _, req := range r.pending {
+		req.alternatives = removePeer(req.alternatives, peer)
+		if req.peer == peer {
+			r.retry(req, now)
+		}
+	}
+}
+
+// watch the deadlines of the requests, until there are none left
+func (r *RequestTracker) watch() {
+	ticker := time.NewTicker(requestTimeout / 3)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if !r.expire(now) {
+			return
+		}
+	}
+}
+
+// expire retries the requests past their deadline. It returns false once
+// there are no requests left, and the deadlines do not need to be watched any
+// longer.
+func (r *RequestTracker) expire(now time.Time) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, req := range r.pending {
+		if now.After(req.deadline) {
+			r.retry(req, now)
+		}
+	}
+
+	if len(r.pending) == 0 {
+		r.watching = false
+		return false
+	}
+
+	return true
+}
+
+// retry requests an item from the next peer which advertised it, or gives it
+// up if there is none. Must be called with the lock held.
+func (r *RequestTracker) retry(req *pendingRequest, now time.Time) {
+	for len(req.alternatives) > 0 {
+		peer := req.alternatives[0]
+		req.alternatives = req.alternatives[1:]
+		if err := requestItem(req.item, peer); err != nil {
+			log.WithFields(log.Fields{
+				"process": "request tracker",
+				"error":   err,
+			}).Debugln("could not request item")
+			continue
+		}
+
+		req.peer = peer
+		req.deadline = now.Add(requestTimeout)
+		return
+	}
+
+	delete(r.pending, hex.EncodeToString(req.item.Hash))
+}
+
+// requestItem sends a GetData for a single item to a peer. The request is
+// dropped if the outgoing queue of the peer is full.
+func requestItem(item peermsg.InvVect, peer chan<- *bytes.Buffer) error {
+	getData := &peermsg.Inv{}
+	getData.AddItem(item.Type, item.Hash)
+	buf, err := marshalGetData(getData)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case peer <- buf:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+func containsPeer(peers []chan<- *bytes.Buffer, peer chan<- *bytes.Buffer) bool {
+	for _, p := range peers {
+		if p == peer {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removePeer(peers []chan<- *bytes.Buffer, peer chan<- *bytes.Buffer) []chan<- *bytes.Buffer {
+	for i, p := range peers {
+		if p == peer {
+			return append(peers[:i], peers[i+1:]...)
+		}
+	}
+
+	return peers
+}
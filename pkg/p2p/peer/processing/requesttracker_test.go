@@ -0,0 +1,65 @@
+package processing_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/stretchr/testify/assert"
+)
+
+// An item should be requested from a single peer, and from the next peer which
+// advertised it when the first one does not have it.
+func TestRetryFromAlternativePeers(t *testing.T) {
+	r := processing.NewRequestTracker()
+	a := make(chan *bytes.Buffer, 10)
+	b := make(chan *bytes.Buffer, 10)
+	c := make(chan *bytes.Buffer, 10)
+	item := peermsg.InvVect{Type: peermsg.InvTypeMempoolTx, Hash: bytes.Repeat([]byte{1}, 32)}
+
+	assert.True(t, r.Advertised(item, a))
+	assert.False(t, r.Advertised(item, b))
+	assert.False(t, r.Advertised(item, c))
+	assert.True(t, r.Requested(item.Hash, a))
+	assert.False(t, r.Requested(item.Hash, b))
+
+	// a does not have the item
+	notFound := &peermsg.Inv{InvList: []peermsg.InvVect{item}}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, notFound.Encode(buf))
+	assert.NoError(t, r.NotFound(buf, a))
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, 0, len(c))
+	assert.True(t, r.Requested(item.Hash, b))
+
+	// a NotFound from a peer we did not ask is ignored
+	buf = new(bytes.Buffer)
+	assert.NoError(t, notFound.Encode(buf))
+	assert.NoError(t, r.NotFound(buf, c))
+	assert.Equal(t, 0, len(c))
+
+	// once delivered, the item is no longer tracked
+	r.Received(item.Hash)
+	assert.False(t, r.Requested(item.Hash, b))
+	assert.True(t, r.Advertised(item, c))
+}
+
+// The items requested from a disconnected peer should be requested from
+// another one.
+func TestForgetPeer(t *testing.T) {
+	r := processing.NewRequestTracker()
+	a := make(chan *bytes.Buffer, 10)
+	b := make(chan *bytes.Buffer, 10)
+	item := peermsg.InvVect{Type: peermsg.InvTypeBlock, Hash: bytes.Repeat([]byte{2}, 32)}
+
+	assert.True(t, r.Advertised(item, a))
+	assert.False(t, r.Advertised(item, b))
+
+	r.Forget(a)
+	assert.Equal(t, 1, len(b))
+
+	// nobody else has it
+	r.Forget(b)
+	assert.True(t, r.Advertised(item, a))
+}
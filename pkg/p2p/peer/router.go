@@ -3,11 +3,13 @@ package peer
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/dupemap"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing/chainsync"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	log "github.com/sirupsen/logrus"
 )
@@ -22,7 +24,24 @@ type messageRouter struct {
 	blockHashBroker *processing.BlockHashBroker
 	dataRequestor   *processing.DataRequestor
 	dataBroker      *processing.DataBroker
+	compactBlocks   *processing.CompactBlocks
 	synchronizer    *chainsync.ChainSynchronizer
+	rejector        *processing.Rejector
+	requests        *processing.RequestTracker
+	invRelay        *processing.InvRelay
+	dandelion       *processing.Dandelion
+	broadcaster     processing.Broadcaster
+	limiter         *processing.RateLimiter
+	addrBroker      *processing.AddrBroker
+	pinger          *processing.Pinger
+
+	// outgoing message queue of the peer
+	responseChan chan<- *bytes.Buffer
+	// penalize adds to the ban score of the peer
+	penalize func(score uint32, reason string)
+	// remote is the version message of the peer, nil if no handshake was
+	// performed
+	remote *VersionMessage
 
 	peerInfo string
 }
@@ -32,14 +51,36 @@ func (m *messageRouter) Collect(b *bytes.Buffer) error {
 	if err != nil {
 		return err
 	}
+
+	// the messages beyond the rate of their topic are dropped, before they
+	// reach the other subsystems. The txs may be replies to our requests,
+	// which are only known once they are decoded.
+	if topic != topics.Tx && !m.allow(topic) {
+		return nil
+	}
+
 	m.route(topic, b)
 	return nil
 }
 
+// allow returns false if the peer exceeded the rate of the topic. The peer is
+// penalized once per overflow, rather than for every message dropped.
+func (m *messageRouter) allow(topic topics.Topic) bool {
+	now := time.Now()
+	if m.limiter.Allow(topic, now) {
+		return true
+	}
+
+	if m.limiter.Exceeded(topic, now) {
+		m.penalize(scoreRateLimited, fmt.Sprintf("%s rate exceeded", string(topic)))
+	}
+
+	return false
+}
+
 func (m *messageRouter) CanRoute(topic topics.Topic) bool {
 	switch topic {
-	case topics.Tx,
-		topics.Candidate,
+	case topics.Candidate,
 		topics.Score,
 		topics.Reduction,
 		topics.Agreement:
@@ -54,17 +95,92 @@ func (m *messageRouter) route(topic topics.Topic, b *bytes.Buffer) {
 	switch topic {
 	case topics.GetBlocks:
 		err = m.blockHashBroker.AdvertiseMissingBlocks(b)
+	case topics.GetHeaders:
+		err = m.blockHashBroker.SendHeaders(b)
+	case topics.Headers:
+		err = m.synchronizer.OnHeaders(b, m.onInvalidBlock)
 	case topics.GetData:
 		err = m.dataBroker.SendItems(b)
 	case topics.MemPool:
 		err = m.dataBroker.SendTxsItems()
 	case topics.Inv:
-		// We only accept an advertisement once
-		if m.dupeMap.CanFwd(b) {
-			err = m.dataRequestor.RequestMissingItems(b)
+		if err = m.invRelay.KnownInv(m.responseChan, b); err != nil {
+			m.penalize(scoreMalformed, "malformed inventory")
+			break
+		}
+
+		// the blocks we are syncing are downloaded from all the peers
+		if m.synchronizer.OnInv(b) {
+			break
 		}
+
+		// the items advertised by several peers are only requested once, but
+		// every peer is remembered in case the request fails
+		err = m.dataRequestor.RequestMissingItems(b, m.servesBlocks(), m.supports(protocol.FeatureCompactBlocks))
 	case topics.Block:
-		err = m.synchronizer.Synchronize(b, m.peerInfo)
+		err = m.routeBlock(b)
+	case topics.CmpctBlock:
+		var blk *bytes.Buffer
+		if blk, err = m.compactBlocks.OnCompactBlock(b); err == processing.ErrMalformedCompactBlock {
+			m.penalize(scoreMalformed, err.Error())
+		} else if blk != nil {
+			err = m.routeBlock(blk)
+		}
+	case topics.GetBlockTxn:
+		err = m.dataBroker.SendBlockTxs(b)
+	case topics.BlockTxn:
+		var blk *bytes.Buffer
+		if blk, err = m.compactBlocks.OnBlockTxn(b); err != nil {
+			m.penalize(scoreUnexpected, "unexpected block txs")
+		} else if blk != nil {
+			err = m.routeBlock(blk)
+		}
+	case topics.Tx:
+		var hash []byte
+		if hash, err = processing.TxHash(b, m.responseChan); err != nil {
+			m.penalize(scoreMalformed, "malformed tx")
+			break
+		}
+
+		// the txs we asked for are not limited
+		if !m.requests.Requested(hash, m.responseChan) && !m.allow(topic) {
+			break
+		}
+
+		if m.dupeMap.CanFwd(b) {
+			m.rejector.Track(hash, m.responseChan)
+			m.requests.Received(hash)
+			m.invRelay.Known(m.responseChan, hash)
+			m.publisher.Publish(string(topic), b)
+		}
+	case topics.StemTx:
+		// stem txs are not marked as known by the peer, so that they are
+		// advertised back to it once they are broadcast
+		var hash []byte
+		if hash, err = processing.TxHash(b, m.responseChan); err != nil {
+			m.penalize(scoreMalformed, "malformed stem tx")
+		} else {
+			m.rejector.Track(hash, m.responseChan)
+			m.dandelion.Received(hash, m.responseChan)
+			m.publisher.Publish(string(topic), b)
+		}
+	case topics.Kadcast:
+		err = m.routeKadcast(b)
+	case topics.NotFound:
+		err = m.requests.NotFound(b, m.responseChan)
+	case topics.Reject:
+		err = processing.LogReject(b, m.peerInfo)
+	case topics.Ping:
+		err = m.pinger.OnPing(b)
+	case topics.Pong:
+		if err = m.pinger.OnPong(b); err != nil {
+			m.penalize(scoreUnexpected, "unexpected pong")
+		}
+	case topics.GetAddr:
+		err = m.addrBroker.SendAddresses()
+	case topics.Addr:
+		// the address manager takes it from here
+		m.publisher.Publish(string(topic), b)
 	default:
 		if m.CanRoute(topic) {
 			if m.dupeMap.CanFwd(b) {
@@ -72,6 +188,7 @@ func (m *messageRouter) route(topic topics.Topic, b *bytes.Buffer) {
 			}
 		} else {
 			err = fmt.Errorf("%s topic not routable", string(topic))
+			m.penalize(scoreUnroutable, err.Error())
 		}
 	}
 
@@ -82,3 +199,67 @@ func (m *messageRouter) route(topic topics.Topic, b *bytes.Buffer) {
 		}).Errorf("problem handling message %s", string(topic))
 	}
 }
+
+// routeKadcast routes a message propagated over the structured overlay. Only
+// the gossip topics can be propagated this way.
+func (m *messageRouter) routeKadcast(b *bytes.Buffer) error {
+	height, err := processing.ReadKadcastHeight(b)
+	if err != nil {
+		m.penalize(scoreMalformed, "malformed kadcast message")
+		return err
+	}
+
+	msg := b.Bytes()
+	topic, err := extractTopic(b)
+	if err != nil {
+		m.penalize(scoreMalformed, "malformed kadcast message")
+		return err
+	}
+
+	if topic != topics.Block && !m.CanRoute(topic) {
+		err := fmt.Errorf("%s topic not routable through kadcast", string(topic))
+		m.penalize(scoreUnroutable, err.Error())
+		return err
+	}
+
+	// the relayed messages count towards the rate of their own topic too
+	if !m.allow(topic) {
+		return nil
+	}
+
+	// the height is needed to propagate the message further, once it is
+	// republished
+	m.broadcaster.Relayed(msg, height)
+	m.route(topic, b)
+	return nil
+}
+
+// routeBlock hands a block received from the peer, in full or rebuilt from a
+// compact block, to the synchronizer
+func (m *messageRouter) routeBlock(b *bytes.Buffer) error {
+	hash, err := m.rejector.TrackBlock(b, m.responseChan, m.onInvalidBlock)
+	if err != nil {
+		m.penalize(scoreMalformed, "malformed block")
+		return err
+	}
+
+	m.requests.Received(hash)
+	m.invRelay.Known(m.responseChan, hash)
+	return m.synchronizer.Synchronize(b, m.peerInfo)
+}
+
+// servesBlocks returns true if full blocks can be requested from the peer.
+// Peers which did not perform the handshake are assumed to be full nodes.
+func (m *messageRouter) servesBlocks() bool {
+	return m.remote == nil || m.remote.ServesBlocks()
+}
+
+// supports returns true if the peer advertised the given protocol features.
+// Peers which did not perform the handshake are assumed to support them all.
+func (m *messageRouter) supports(f protocol.Features) bool {
+	return m.remote == nil || m.remote.Features.Has(f)
+}
+
+func (m *messageRouter) onInvalidBlock(reason string) {
+	m.penalize(scoreInvalidBlock, "invalid block: "+reason)
+}
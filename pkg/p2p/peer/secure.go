@@ -0,0 +1,279 @@
+package peer
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The secure handshake is a three-message exchange modeled after the Noise XX
+// pattern, with the static keys replaced by the ed25519 identities of the
+// nodes:
+//
+//	-> e_i
+//	<- e_r, seal(id_r, sig_r)
+//	-> seal(id_i, sig_i)
+//
+// Both ends derive a pair of ChaCha20-Poly1305 keys from the X25519 exchange
+// of the ephemeral keys, and sign the transcript hash of the ephemeral keys
+// with their identity, which binds the identities to the session. The
+// identities are only sent encrypted.
+
+const (
+	handshakeProtocol = "dusk-p2p-handshake-v1"
+
+	roleInitiator byte = 0
+	roleResponder byte = 1
+
+	keySize = 32
+)
+
+var (
+	// ErrHandshakeAuth is returned when the peer fails to prove its identity
+	ErrHandshakeAuth = errors.New("peer identity could not be verified")
+
+	// ErrDecryption is returned when a frame does not pass authentication
+	ErrDecryption = errors.New("frame could not be decrypted")
+)
+
+// session holds the AEAD ciphers of an established secure channel. The send
+// and receive halves are used by the peer Writer and Reader respectively.
+type session struct {
+	send      cipher.AEAD
+	sendNonce uint64
+
+	recv      cipher.AEAD
+	recvNonce uint64
+}
+
+func nonce(counter uint64) []byte {
+	n := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(n[4:], counter)
+	return n
+}
+
+// seal encrypts a payload, and authenticates it along with the additional
+// data, which is sent in the clear. Payloads must be written to the wire in the
+// order they are sealed.
+func (s *session) seal(payload, additionalData []byte) []byte {
+	ct := s.send.Seal(nil, nonce(s.sendNonce), payload, additionalData)
+	s.sendNonce++
+	return ct
+}
+
+func (s *session) open(ciphertext, additionalData []byte) ([]byte, error) {
+	payload, err := s.recv.Open(nil, nonce(s.recvNonce), ciphertext, additionalData)
+	s.recvNonce++
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return payload, nil
+}
+
+// frameData returns the additional data authenticated along with the payload
+// of a frame: the compressed flag, which sits in the clear in the frame header.
+func frameData(compressed bool) []byte {
+	if compressed {
+		return []byte{1}
+	}
+
+	return nil
+}
+
+// skip accounts for a received frame which could not be opened
+func (s *session) skip() {
+	s.recvNonce++
+}
+
+type ephemeral struct {
+	priv [keySize]byte
+	pub  [keySize]byte
+}
+
+func newEphemeral() (*ephemeral, error) {
+	e := &ephemeral{}
+	if _, err := rand.Read(e.priv[:]); err != nil {
+		return nil, err
+	}
+
+	curve25519.ScalarBaseMult(&e.pub, &e.priv)
+	return e, nil
+}
+
+// deriveSession computes the shared secret with the remote ephemeral key, and
+// returns the transcript hash and the session keys of the given role.
+func deriveSession(e *ephemeral, remote []byte, role byte) ([]byte, *session, error) {
+	if len(remote) != keySize {
+		return nil, nil, errors.New("invalid ephemeral key")
+	}
+
+	var remoteKey, shared [keySize]byte
+	copy(remoteKey[:], remote)
+	curve25519.ScalarMult(&shared, &e.priv, &remoteKey)
+
+	// reject low order points, which would give a known shared secret
+	var zero [keySize]byte
+	if subtle.ConstantTimeCompare(shared[:], zero[:]) == 1 {
+		return nil, nil, errors.New("invalid ephemeral key")
+	}
+
+	// the transcript hash covers the ephemeral keys in initiator order
+	h := sha256.New()
+	_, _ = h.Write([]byte(handshakeProtocol))
+	if role == roleInitiator {
+		_, _ = h.Write(e.pub[:])
+		_, _ = h.Write(remote)
+	} else {
+		_, _ = h.Write(remote)
+		_, _ = h.Write(e.pub[:])
+	}
+	transcript := h.Sum(nil)
+
+	keys := make([]byte, 2*keySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared[:], transcript, []byte(handshakeProtocol)), keys); err != nil {
+		return nil, nil, err
+	}
+
+	initiatorKey, err := chacha20poly1305.New(keys[:keySize])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responderKey, err := chacha20poly1305.New(keys[keySize:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if role == roleInitiator {
+		return transcript, &session{send: initiatorKey, recv: responderKey}, nil
+	}
+
+	return transcript, &session{send: responderKey, recv: initiatorKey}, nil
+}
+
+// authPayload is the identity of a node, with its signature of the transcript
+func authPayload(identity ed25519.PrivateKey, transcript []byte, role byte) []byte {
+	pub := identity.Public().(ed25519.PublicKey)
+	sig := ed25519.Sign(identity, append(append([]byte{}, transcript...), role))
+	return append(append([]byte{}, pub...), sig...)
+}
+
+func verifyAuth(payload, transcript []byte, role byte) (ed25519.PublicKey, error) {
+	if len(payload) != ed25519.PublicKeySize+ed25519.SignatureSize {
+		return nil, ErrHandshakeAuth
+	}
+
+	id := ed25519.PublicKey(payload[:ed25519.PublicKeySize])
+	if !ed25519.Verify(id, append(append([]byte{}, transcript...), role), payload[ed25519.PublicKeySize:]) {
+		return nil, ErrHandshakeAuth
+	}
+
+	return id, nil
+}
+
+// secureInitiate performs the initiator side of the secure handshake
+func (c *Connection) secureInitiate(identity ed25519.PrivateKey) error {
+	e, err := newEphemeral()
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeHandshakeFrame(e.pub[:]); err != nil {
+		return err
+	}
+
+	msg, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	if len(msg) < keySize {
+		return errors.New("invalid handshake message")
+	}
+
+	transcript, s, err := deriveSession(e, msg[:keySize], roleInitiator)
+	if err != nil {
+		return err
+	}
+
+	payload, err := s.open(msg[keySize:], nil)
+	if err != nil {
+		return ErrHandshakeAuth
+	}
+
+	id, err := verifyAuth(payload, transcript, roleResponder)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeHandshakeFrame(s.seal(authPayload(identity, transcript, roleInitiator), nil)); err != nil {
+		return err
+	}
+
+	c.session = s
+	c.remoteID = id
+	return nil
+}
+
+// secureRespond performs the responder side of the secure handshake
+func (c *Connection) secureRespond(identity ed25519.PrivateKey) error {
+	msg, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	e, err := newEphemeral()
+	if err != nil {
+		return err
+	}
+
+	transcript, s, err := deriveSession(e, msg, roleResponder)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeHandshakeFrame(append(e.pub[:], s.seal(authPayload(identity, transcript, roleResponder), nil)...)); err != nil {
+		return err
+	}
+
+	msg, err = c.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	payload, err := s.open(msg, nil)
+	if err != nil {
+		return ErrHandshakeAuth
+	}
+
+	id, err := verifyAuth(payload, transcript, roleInitiator)
+	if err != nil {
+		return err
+	}
+
+	c.session = s
+	c.remoteID = id
+	return nil
+}
+
+func (c *Connection) writeHandshakeFrame(payload []byte) error {
+	frame, err := processing.WriteFrame(bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Write(frame.Bytes())
+	return err
+}
@@ -0,0 +1,85 @@
+package peer
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+// Test that the secure handshake authenticates both ends, and that the frames
+// exchanged afterwards are encrypted and protected against tampering.
+func TestSecureChannel(t *testing.T) {
+	client, srv := net.Pipe()
+	initiator := &Connection{Conn: client}
+	responder := &Connection{Conn: srv}
+
+	_, initiatorKey, _ := ed25519.GenerateKey(nil)
+	_, responderKey, _ := ed25519.GenerateKey(nil)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- responder.secureRespond(responderKey)
+	}()
+
+	assert.NoError(t, initiator.secureInitiate(initiatorKey))
+	assert.NoError(t, <-errChan)
+	assert.Equal(t, responderKey.Public(), initiator.remoteID)
+	assert.Equal(t, initiatorKey.Public(), responder.remoteID)
+
+	payload := []byte("pippo")
+	frame, err := processing.WriteFrame(bytes.NewBuffer(payload))
+	assert.NoError(t, err)
+
+	// capture what goes on the wire
+	wire := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 1024)
+		n, _ := srv.Read(buf)
+		wire <- buf[:n]
+	}()
+
+	_, err = initiator.Write(frame.Bytes())
+	assert.NoError(t, err)
+	sent := <-wire
+	assert.False(t, bytes.Contains(sent, payload))
+
+	// an intact frame is decrypted
+	read, err := responder.session.open(sent[8:], nil)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, read)
+
+	// a tampered frame is rejected
+	tampered := initiator.session.seal(payload, nil)
+	tampered[0] ^= 0xff
+	_, err = responder.session.open(tampered, nil)
+	assert.Equal(t, ErrDecryption, err)
+
+	// and so is a frame whose compressed flag was flipped
+	flipped := initiator.session.seal(payload, frameData(false))
+	_, err = responder.session.open(flipped, frameData(true))
+	assert.Equal(t, ErrDecryption, err)
+}
+
+// Test that a peer signing with a different identity than the one it sends
+// is rejected.
+func TestSecureHandshakeForgedIdentity(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+
+	transcript := make([]byte, 32)
+	payload := authPayload(key, transcript, roleInitiator)
+	_, err := verifyAuth(payload, transcript, roleInitiator)
+	assert.NoError(t, err)
+
+	// the signature is bound to the role
+	_, err = verifyAuth(payload, transcript, roleResponder)
+	assert.Equal(t, ErrHandshakeAuth, err)
+
+	copy(payload, other)
+	_, err = verifyAuth(payload, transcript, roleInitiator)
+	assert.Equal(t, ErrHandshakeAuth, err)
+}
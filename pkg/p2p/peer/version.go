@@ -6,6 +6,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 )
@@ -15,9 +16,20 @@ type VersionMessage struct {
 	Version   *protocol.Version
 	Timestamp int64
 	Services  protocol.ServiceFlag
+	// FrameVersion is the highest frame format supported by the node
+	FrameVersion processing.FrameVersion
+	// BestHeight is the height of the chain tip of the node when connecting
+	BestHeight uint64
+	// Features are the optional parts of the protocol supported by the node
+	Features protocol.Features
 }
 
-func newVersionMessageBuffer(v *protocol.Version, services protocol.ServiceFlag) (*bytes.Buffer, error) {
+// ServesBlocks returns true if full blocks can be requested from the node
+func (v *VersionMessage) ServesBlocks() bool {
+	return v.Services.Has(protocol.FullNode)
+}
+
+func newVersionMessageBuffer(v *protocol.Version, services protocol.ServiceFlag, bestHeight uint64, features protocol.Features) (*bytes.Buffer, error) {
 	buffer := new(bytes.Buffer)
 	if err := v.Encode(buffer); err != nil {
 		return nil, err
@@ -32,6 +44,18 @@ func newVersionMessageBuffer(v *protocol.Version, services protocol.ServiceFlag)
 		return nil, err
 	}
 
+	if err := encoding.WriteUint8(buffer, uint8(processing.CurrentFrameVersion)); err != nil {
+		return nil, err
+	}
+
+	if err := encoding.WriteUint64(buffer, binary.LittleEndian, bestHeight); err != nil {
+		return nil, err
+	}
+
+	if err := encoding.WriteUint64(buffer, binary.LittleEndian, uint64(features)); err != nil {
+		return nil, err
+	}
+
 	return buffer, nil
 }
 
@@ -56,5 +80,24 @@ func decodeVersionMessage(r io.Reader) (*VersionMessage, error) {
 	}
 
 	versionMessage.Services = protocol.ServiceFlag(services)
+
+	var frameVersion uint8
+	if err := encoding.ReadUint8(r, &frameVersion); err != nil {
+		return nil, err
+	}
+
+	versionMessage.FrameVersion = processing.FrameVersion(frameVersion)
+
+	var bestHeight, features uint64
+	if err := encoding.ReadUint64(r, binary.LittleEndian, &bestHeight); err != nil {
+		return nil, err
+	}
+
+	if err := encoding.ReadUint64(r, binary.LittleEndian, &features); err != nil {
+		return nil, err
+	}
+
+	versionMessage.BestHeight = bestHeight
+	versionMessage.Features = protocol.Features(features)
 	return versionMessage, nil
 }
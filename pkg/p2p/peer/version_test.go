@@ -0,0 +1,29 @@
+package peer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/processing"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that the version message is decoded with all its fields, and that a
+// message missing the trailing ones is refused.
+func TestDecodeVersionMessage(t *testing.T) {
+	buf, err := newVersionMessageBuffer(protocol.NodeVer, protocol.FullNode, 42, protocol.LocalFeatures|protocol.FeatureKadcast)
+	assert.NoError(t, err)
+	b := buf.Bytes()
+
+	version, err := decodeVersionMessage(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.NodeVer.Major, version.Version.Major)
+	assert.Equal(t, processing.CurrentFrameVersion, version.FrameVersion)
+	assert.Equal(t, uint64(42), version.BestHeight)
+	assert.Equal(t, protocol.LocalFeatures|protocol.FeatureKadcast, version.Features)
+
+	// drop the features
+	_, err = decodeVersionMessage(bytes.NewReader(b[:len(b)-8]))
+	assert.Error(t, err)
+}
@@ -14,15 +14,55 @@ const (
 	// FullNode indicates that a user is running the full node implementation of Dusk
 	FullNode ServiceFlag = 1
 
-	// LightNode indicates that a user is running a Dusk light node
-	// LightNode ServiceFlag = 2 // Not implemented
+	// LightNode indicates that a user is running a Dusk light node, which
+	// does not store nor serve full blocks
+	LightNode ServiceFlag = 2
 )
 
-// NodeVer is the current node version.
+// Has returns true if all the services of flag are provided
+func (s ServiceFlag) Has(flag ServiceFlag) bool {
+	return s&flag == flag
+}
+
+// Features is a bitmask of the optional parts of the protocol a node supports.
+// A node only relies on the features advertised by its peers.
+type Features uint64
+
+const (
+	// FeatureAddr indicates that the node exchanges peer addresses through
+	// the GetAddr and Addr messages
+	FeatureAddr Features = 1 << iota
+	// FeaturePing indicates that the node answers the Ping messages
+	FeaturePing
+	// FeatureHeaders indicates that the node serves block headers through the
+	// GetHeaders and Headers messages
+	FeatureHeaders
+	// FeatureCompactBlocks indicates that the node serves blocks as compact
+	// blocks, through the CmpctBlock, GetBlockTxn and BlockTxn messages
+	FeatureCompactBlocks
+	// FeatureDandelion indicates that the node relays the txs in their stem
+	// phase, received through the StemTx message
+	FeatureDandelion
+	// FeatureKadcast indicates that the node accepts the gossip propagated
+	// over the structured overlay, through the Kadcast message
+	FeatureKadcast
+)
+
+// LocalFeatures are the features supported by this implementation. The
+// FeatureKadcast bit is only advertised by the nodes which run the overlay.
+const LocalFeatures = FeatureAddr | FeaturePing | FeatureHeaders | FeatureCompactBlocks | FeatureDandelion
+
+// Has returns true if all the features of f are supported
+func (s Features) Has(f Features) bool {
+	return s&f == f
+}
+
+// NodeVer is the current node version. The major version is bumped whenever
+// the handshake changes, since nodes with a different major version are refused.
 var NodeVer = &Version{
-	Major: 0,
-	Minor: 1,
-	Patch: 1,
+	Major: 1,
+	Minor: 0,
+	Patch: 0,
 }
 
 // Magic is the network that Dusk is running on
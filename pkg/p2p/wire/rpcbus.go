@@ -32,16 +32,99 @@ var (
 	GetLastBlockChan chan Req
 
 	// Provide the list of verified txs ready to be included into next block
-	// Param 1: list of TxIDs to request. If empty, returns all available txs
+	// Param 1: list of TxIDs to request, or a uint32 maximum amount of txs to
+	// return. If empty, returns all available txs
 	// Implemented by mempool
 	GetMempoolTxs     = "getMempoolTxs"
 	GetMempoolTxsChan chan Req
 
+	// Provide the verified txs to be included into a candidate block,
+	// prioritized by fee rate
+	// Param 1: uint32 max overall size (in bytes) of the txs
+	// Implemented by mempool
+	GetMempoolTxsBySize     = "getMempoolTxsBySize"
+	GetMempoolTxsBySizeChan chan Req
+
+	// Verify a tx and add it to the mempool
+	// Param 1: the encoded tx
+	// Returns the tx hash if accepted, or a *peermsg.Reject error otherwise
+	// Implemented by mempool
+	SendMempoolTx     = "sendMempoolTx"
+	SendMempoolTxChan chan Req
+
+	// Suggest a fee for a tx to be included in a block
+	// Param 1: uint32 confirmation target, in blocks
+	// Returns mempool.FeeEstimate marshaled
+	// Implemented by mempool
+	EstimateFee     = "estimateFee"
+	EstimateFeeChan chan Req
+
+	// Provide a summary of the verified pool (count, size, fee rates and
+	// fee-rate histogram)
+	// Returns mempool.Info marshaled
+	// Implemented by mempool
+	GetMempoolInfo     = "getMempoolInfo"
+	GetMempoolInfoChan chan Req
+
+	// Provide the meta data of the verified txs
+	// Param 1: TxID to request. If empty, returns all entries
+	// Returns a list of mempool.Entry marshaled
+	// Implemented by mempool
+	GetMempoolEntries     = "getMempoolEntries"
+	GetMempoolEntriesChan chan Req
+
+	// Provide the state of the connected peers
+	// Returns a list of peermgr.Stats marshaled
+	// Implemented by the peer manager
+	GetPeerInfo     = "getPeerInfo"
+	GetPeerInfoChan chan Req
+
+	// Provide a selection of known node addresses
+	// Param 1: uint16 max amount of addresses
+	// Returns peermsg.Addr marshaled
+	// Implemented by the address manager
+	GetAddresses     = "getAddresses"
+	GetAddressesChan chan Req
+
+	// Provide the active bans
+	// Returns the list of peermgr.Ban marshaled
+	// Implemented by the peer manager
+	GetBans     = "getBans"
+	GetBansChan chan Req
+
+	// Ban an IP or a peer ID
+	// Param 1: string IP or hex encoded peer ID
+	// Param 2: uint64 duration in seconds, zero for the default
+	// Param 3: string reason
+	// Implemented by the peer manager
+	AddBan     = "addBan"
+	AddBanChan chan Req
+
+	// Lift the ban of an IP or a peer ID
+	// Param 1: string IP or hex encoded peer ID
+	// Implemented by the peer manager
+	RemoveBan     = "removeBan"
+	RemoveBanChan chan Req
+
 	// Verify a specified candidate block
 	//
 	// Used by the reduction component.
 	VerifyCandidateBlock     = "verifyCandidateBlock"
 	VerifyCandidateBlockChan chan Req
+
+	// Provide the locator of the local chain: the hashes of the blocks from the
+	// tip back to the genesis, sparser and sparser further back
+	// Returns peermsg.GetBlocks marshaled
+	// Implemented by Chain
+	GetBlockLocator     = "getBlockLocator"
+	GetBlockLocatorChan chan Req
+
+	// Verify the certificates of a chain of headers, following the local tip
+	// Param 1: peermsg.Headers marshaled
+	// Returns the uint64 amount of leading headers with a valid certificate
+	// Implemented by Chain
+	VerifyHeaders     = "verifyHeaders"
+	VerifyHeadersChan chan Req
 )
 
 // RPCBus is a request–response mechanism for internal communication between node
@@ -87,11 +170,71 @@ func NewRPCBus() *RPCBus {
 		panic(err)
 	}
 
+	GetMempoolTxsBySizeChan = make(chan Req)
+	if err := bus.Register(GetMempoolTxsBySize, GetMempoolTxsBySizeChan); err != nil {
+		panic(err)
+	}
+
+	SendMempoolTxChan = make(chan Req)
+	if err := bus.Register(SendMempoolTx, SendMempoolTxChan); err != nil {
+		panic(err)
+	}
+
+	EstimateFeeChan = make(chan Req)
+	if err := bus.Register(EstimateFee, EstimateFeeChan); err != nil {
+		panic(err)
+	}
+
+	GetMempoolInfoChan = make(chan Req)
+	if err := bus.Register(GetMempoolInfo, GetMempoolInfoChan); err != nil {
+		panic(err)
+	}
+
+	GetMempoolEntriesChan = make(chan Req)
+	if err := bus.Register(GetMempoolEntries, GetMempoolEntriesChan); err != nil {
+		panic(err)
+	}
+
+	GetPeerInfoChan = make(chan Req)
+	if err := bus.Register(GetPeerInfo, GetPeerInfoChan); err != nil {
+		panic(err)
+	}
+
+	GetAddressesChan = make(chan Req)
+	if err := bus.Register(GetAddresses, GetAddressesChan); err != nil {
+		panic(err)
+	}
+
+	GetBansChan = make(chan Req)
+	if err := bus.Register(GetBans, GetBansChan); err != nil {
+		panic(err)
+	}
+
+	AddBanChan = make(chan Req)
+	if err := bus.Register(AddBan, AddBanChan); err != nil {
+		panic(err)
+	}
+
+	RemoveBanChan = make(chan Req)
+	if err := bus.Register(RemoveBan, RemoveBanChan); err != nil {
+		panic(err)
+	}
+
 	VerifyCandidateBlockChan = make(chan Req)
 	if err := bus.Register(VerifyCandidateBlock, VerifyCandidateBlockChan); err != nil {
 		panic(err)
 	}
 
+	GetBlockLocatorChan = make(chan Req)
+	if err := bus.Register(GetBlockLocator, GetBlockLocatorChan); err != nil {
+		panic(err)
+	}
+
+	VerifyHeadersChan = make(chan Req)
+	if err := bus.Register(VerifyHeaders, VerifyHeadersChan); err != nil {
+		panic(err)
+	}
+
 	return &bus
 }
 
@@ -26,12 +26,16 @@ const (
 	GetBlocks     Topic = "getblocks"
 	GetHeaders    Topic = "getheaders"
 	Tx            Topic = "tx"
+	StemTx        Topic = "stemtx"
 	Block         Topic = "block"
 	AcceptedBlock Topic = "acceptedblock"
 	Headers       Topic = "headers"
 	MemPool       Topic = "mempool"
 	Inv           Topic = "inv"
 	Certificate   Topic = "certificate"
+	CmpctBlock    Topic = "cmpctblock"
+	GetBlockTxn   Topic = "getblocktxn"
+	BlockTxn      Topic = "blocktxn"
 
 	// Consensus topics
 	Candidate      Topic = "candidate"
@@ -41,7 +45,9 @@ const (
 	StartConsensus Topic = "startconsensus"
 
 	// Peer topics
-	Gossip Topic = "gossip"
+	Gossip  Topic = "gossip"
+	Stem    Topic = "stem"
+	Kadcast Topic = "kadcast"
 
 	// Blockchain topics
 	ChainInfo Topic = "chaininfo"
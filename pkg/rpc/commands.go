@@ -2,18 +2,24 @@ package rpc
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"strconv"
+	"strings"
 	"time"
 
 	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/mempool"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermgr"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/peermsg"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
@@ -32,14 +38,32 @@ var (
 		"uptime":        uptime,
 		"getLastBlock":  getlastblock,
 		"getMempoolTxs": getmempooltxs,
+		"sendTx":        sendtx,
+		// Mempool introspection
+		"getMempoolInfo":      getmempoolinfo,
+		"getMempoolEntries":   getmempoolentries,
+		"getMempoolHistogram": getmempoolhistogram,
+		"getSupply":           getsupply,
+		"estimateFee":         estimatefee,
+		"getPeerInfo":         getpeerinfo,
+		"listBans":            listbans,
+		"addBan":              addban,
+		"removeBan":           removeban,
 		// Publish Topic (experimental). Injects an event directly into EventBus system.
 		// Would be useful on E2E testing. Mind the supportedTopics list when sends it
 		"publishTopic": publishTopic,
 		"exportData":   exportData,
 	}
 
-	// rpcAdminCmd holds all admin methods.
-	rpcAdminCmd = map[string]bool{}
+	// rpcAdminCmd holds all admin methods. Besides the ban management, the
+	// methods revealing the peers or the stem txs of the node are restricted.
+	rpcAdminCmd = map[string]bool{
+		"getMempoolEntries": true,
+		"getPeerInfo":       true,
+		"listBans":          true,
+		"addBan":            true,
+		"removeBan":         true,
+	}
 
 	// supported topics for injection into EventBus
 	supportedTopics = [2]string{
@@ -80,27 +104,319 @@ var getlastblock = func(s *Server, params []string) (string, error) {
 	return string(res), err
 }
 
+// defaultMempoolTxsLimit is the amount of txs returned by getmempooltxs, when
+// no limit is given
+const defaultMempoolTxsLimit = 50
+
+// getmempooltxs returns the verified txs, up to an optional limit.
 var getmempooltxs = func(s *Server, params []string) (string, error) {
+	limit := uint64(defaultMempoolTxsLimit)
+	if len(params) > 0 {
+		var err error
+		limit, err = strconv.ParseUint(params[0], 10, 32)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	txs := make([]transactions.Transaction, 0)
+	if limit > 0 {
+		// the mempool stops collecting the txs once the limit is reached
+		buf := new(bytes.Buffer)
+		if err := encoding.WriteUint32(buf, binary.LittleEndian, uint32(limit)); err != nil {
+			return "", err
+		}
+
+		r, err := s.rpcBus.Call(wire.GetMempoolTxs, wire.NewRequest(*buf, 1))
+		if err != nil {
+			return "", err
+		}
+
+		lTxs, err := encoding.ReadVarInt(&r)
+		if err != nil {
+			return "", err
+		}
+
+		txs, err = transactions.FromReader(&r, lTxs)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	res, err := json.MarshalIndent(txs, "", "\t")
+	return string(res), err
+}
 
-	r, err := s.rpcBus.Call(wire.GetMempoolTxs, wire.NewRequest(bytes.Buffer{}, 1))
+// getmempoolinfo returns the count, overall size, minimum fee rate and age of
+// the oldest entry of the verified txs.
+var getmempoolinfo = func(s *Server, params []string) (string, error) {
+	info, err := callMempoolInfo(s)
 	if err != nil {
 		return "", err
 	}
 
-	lTxs, err := encoding.ReadVarInt(&r)
+	// the histogram has a call on its own
+	info.Histogram = nil
+	res, err := json.MarshalIndent(info, "", "\t")
+	return string(res), err
+}
+
+// getmempoolhistogram returns the verified txs grouped by fee rate.
+var getmempoolhistogram = func(s *Server, params []string) (string, error) {
+	info, err := callMempoolInfo(s)
 	if err != nil {
 		return "", err
 	}
 
-	txs, err := transactions.FromReader(&r, lTxs)
+	res, err := json.MarshalIndent(info.Histogram, "", "\t")
+	return string(res), err
+}
+
+// getmempoolentries returns the meta data of the verified txs. An optional
+// hex-encoded TxID restricts the result to a single entry.
+var getmempoolentries = func(s *Server, params []string) (string, error) {
+	var txID []byte
+	if len(params) > 0 {
+		var err error
+		txID, err = hex.DecodeString(params[0])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	r, err := s.rpcBus.Call(wire.GetMempoolEntries, wire.NewRequest(*bytes.NewBuffer(txID), 1))
 	if err != nil {
 		return "", err
 	}
 
-	res, err := json.MarshalIndent(txs, "", "\t")
+	entries, err := mempool.DecodeEntries(&r)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := json.MarshalIndent(entries, "", "\t")
 	return string(res), err
 }
 
+func callMempoolInfo(s *Server) (*mempool.Info, error) {
+	r, err := s.rpcBus.Call(wire.GetMempoolInfo, wire.NewRequest(bytes.Buffer{}, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &mempool.Info{}
+	if err := info.Decode(&r); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// estimatefee suggests a fee rate (per kB), as well as a fee for a tx of
+// average size, to be included within an optional target amount of blocks.
+var estimatefee = func(s *Server, params []string) (string, error) {
+	target := uint64(mempool.DefaultEstimateTarget)
+	if len(params) > 0 {
+		var err error
+		target, err = strconv.ParseUint(params[0], 10, 32)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteUint32(buf, binary.LittleEndian, uint32(target)); err != nil {
+		return "", err
+	}
+
+	r, err := s.rpcBus.Call(wire.EstimateFee, wire.NewRequest(*buf, 1))
+	if err != nil {
+		return "", err
+	}
+
+	estimate := mempool.FeeEstimate{}
+	if err := estimate.Decode(&r); err != nil {
+		return "", err
+	}
+
+	res, err := json.MarshalIndent(estimate, "", "\t")
+	return string(res), err
+}
+
+// getpeerinfo returns the state of the connected peers.
+var getpeerinfo = func(s *Server, params []string) (string, error) {
+	r, err := s.rpcBus.Call(wire.GetPeerInfo, wire.NewRequest(bytes.Buffer{}, 1))
+	if err != nil {
+		return "", err
+	}
+
+	peers, err := peermgr.DecodeStats(&r)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := json.MarshalIndent(peers, "", "\t")
+	return string(res), err
+}
+
+var listbans = func(s *Server, params []string) (string, error) {
+	r, err := s.rpcBus.Call(wire.GetBans, wire.NewRequest(bytes.Buffer{}, 1))
+	if err != nil {
+		return "", err
+	}
+
+	bans, err := peermgr.DecodeBans(&r)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := json.MarshalIndent(bans, "", "\t")
+	return string(res), err
+}
+
+// addban bans an IP or a peer ID. Params are the target, and optionally the
+// duration in seconds and the reason.
+var addban = func(s *Server, params []string) (string, error) {
+	if len(params) < 1 {
+		return "", errors.New("missing IP address or peer ID")
+	}
+
+	var seconds uint64
+	if len(params) > 1 {
+		var err error
+		seconds, err = strconv.ParseUint(params[1], 10, 64)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	reason := "banned by the node operator"
+	if len(params) > 2 {
+		reason = strings.Join(params[2:], " ")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteString(buf, params[0]); err != nil {
+		return "", err
+	}
+
+	if err := encoding.WriteUint64(buf, binary.LittleEndian, seconds); err != nil {
+		return "", err
+	}
+
+	if err := encoding.WriteString(buf, reason); err != nil {
+		return "", err
+	}
+
+	if _, err := s.rpcBus.Call(wire.AddBan, wire.NewRequest(*buf, 1)); err != nil {
+		return "", err
+	}
+
+	return "ok", nil
+}
+
+var removeban = func(s *Server, params []string) (string, error) {
+	if len(params) < 1 {
+		return "", errors.New("missing IP address or peer ID")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encoding.WriteString(buf, params[0]); err != nil {
+		return "", err
+	}
+
+	if _, err := s.rpcBus.Call(wire.RemoveBan, wire.NewRequest(*buf, 1)); err != nil {
+		return "", err
+	}
+
+	return "ok", nil
+}
+
+// supplyResult is the response to a getSupply call
+type supplyResult struct {
+	Height uint64 `json:"height"`
+	// Supply is the amount of DUSK minted up to, and including, Height
+	Supply uint64 `json:"supply"`
+	// Reward is the amount of DUSK minted by the block at Height
+	Reward uint64 `json:"reward"`
+}
+
+// getsupply returns the circulating supply as of the given height. If no
+// height is provided, the height of the last block is used.
+var getsupply = func(s *Server, params []string) (string, error) {
+	var height uint64
+	if len(params) > 0 {
+		var err error
+		height, err = strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		r, err := s.rpcBus.Call(wire.GetLastBlock, wire.NewRequest(bytes.Buffer{}, 1))
+		if err != nil {
+			return "", err
+		}
+
+		b := &block.Block{}
+		if err := b.Decode(&r); err != nil {
+			return "", err
+		}
+
+		height = b.Header.Height
+	}
+
+	emission := cfg.Emission()
+	res := supplyResult{
+		Height: height,
+		Supply: emission.Supply(height),
+		Reward: emission.Reward(height),
+	}
+
+	out, err := json.MarshalIndent(res, "", "\t")
+	return string(out), err
+}
+
+// txResult is the response to a sendTx call. If the tx is rejected, the code
+// and reason of the rejection are included.
+type txResult struct {
+	TxID     string             `json:"txid"`
+	Accepted bool               `json:"accepted"`
+	Code     peermsg.RejectCode `json:"code,omitempty"`
+	CodeName string             `json:"codeName,omitempty"`
+	Reason   string             `json:"reason,omitempty"`
+}
+
+// sendtx submits a hex-encoded tx to the mempool, and reports whether it was
+// accepted.
+var sendtx = func(s *Server, params []string) (string, error) {
+	if len(params) < 1 {
+		return "", errors.New("expects a hex-encoded tx as input param")
+	}
+
+	txBytes, err := hex.DecodeString(params[0])
+	if err != nil {
+		return "", err
+	}
+
+	var res txResult
+	r, err := s.rpcBus.Call(wire.SendMempoolTx, wire.NewRequest(*bytes.NewBuffer(txBytes), 5))
+	switch err := err.(type) {
+	case nil:
+		res.TxID = hex.EncodeToString(r.Bytes())
+		res.Accepted = true
+	case *peermsg.Reject:
+		res.TxID = hex.EncodeToString(err.Hash)
+		res.Code = err.Code
+		res.CodeName = err.Code.String()
+		res.Reason = err.Reason
+	default:
+		return "", err
+	}
+
+	out, err := json.MarshalIndent(res, "", "\t")
+	return string(out), err
+}
+
 var publishTopic = func(s *Server, params []string) (string, error) {
 
 	if len(params) < 2 {